@@ -557,6 +557,13 @@ func (hs *HTTPServer) registerRoutes() {
 
 		// short urls
 		apiRoute.Post("/short-urls", routing.Wrap(hs.createShortURL))
+
+		// render tokens
+		apiRoute.Post("/render/tokens", hs.CreateRenderToken)
+		apiRoute.Get("/render/load", reqGrafanaAdmin, routing.Wrap(hs.GetRenderLoad))
+		apiRoute.Get("/render/folders/:uid/contact-sheet", requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow), routing.Wrap(hs.GetFolderContactSheet))
+		apiRoute.Get("/render/dashboards/:uid/panels", requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow), routing.Wrap(hs.RenderDashboardPanels))
+		apiRoute.Get("/render/templates/:name", requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow), hs.RenderTemplate)
 	}, reqSignedIn)
 
 	// admin api
@@ -599,7 +606,8 @@ func (hs *HTTPServer) registerRoutes() {
 	}, reqSignedIn)
 
 	// rendering
-	r.Get("/render/*", requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow), reqSignedIn, hs.RenderHandler)
+	r.Get("/render/*", requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow), hs.renderTokenAuth, reqSignedIn, hs.RenderHandler)
+	r.Post("/render/*", requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow), hs.renderTokenAuth, reqSignedIn, hs.RenderHandler)
 
 	// grafana.net proxy
 	r.Any("/api/gnet/*", requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow), reqSignedIn, hs.ProxyGnetRequest)
@@ -8,6 +8,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
 	"github.com/grafana/grafana/pkg/components/apikeygen"
 	"github.com/grafana/grafana/pkg/services/apikey"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
@@ -54,6 +55,8 @@ func (hs *HTTPServer) GetAPIKeys(c *contextmodel.ReqContext) response.Response {
 			Role:       t.Role,
 			Expiration: expiration,
 			LastUsedAt: t.LastUsedAt,
+			CreatedBy:  t.CreatedBy,
+			CreatedAt:  t.Created,
 		}
 	}
 
@@ -113,6 +116,8 @@ func (hs *HTTPServer) DeleteAPIKey(c *contextmodel.ReqContext) response.Response
 //
 // see: https://grafana.com/docs/grafana/next/administration/api-keys/#migrate-api-keys-to-grafana-service-accounts-using-the-api.
 //
+// With validateOnly set, the command is validated without creating the key.
+//
 // Responses:
 // 200: postAPIkeyResponse
 // 400: badRequestError
@@ -125,23 +130,21 @@ func (hs *HTTPServer) AddAPIKey(c *contextmodel.ReqContext) response.Response {
 	if err := web.Bind(c.Req, &cmd); err != nil {
 		return response.Error(http.StatusBadRequest, "bad request data", err)
 	}
-	if !cmd.Role.IsValid() {
-		return response.Error(http.StatusBadRequest, "Invalid role specified", nil)
+
+	fieldErrs, err := hs.validateAPIKey(c, &cmd)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to validate API Key", err)
 	}
-	if !c.SignedInUser.GetOrgRole().Includes(cmd.Role) {
-		return response.Error(http.StatusForbidden, "Cannot assign a role higher than user's role", nil)
+	if cmd.ValidateOnly {
+		return apiKeyValidationResponse(fieldErrs)
 	}
-
-	if hs.Cfg.ApiKeyMaxSecondsToLive != -1 {
-		if cmd.SecondsToLive == 0 {
-			return response.Error(http.StatusBadRequest, "Number of seconds before expiration should be set", nil)
-		}
-		if cmd.SecondsToLive > hs.Cfg.ApiKeyMaxSecondsToLive {
-			return response.Error(http.StatusBadRequest, "Number of seconds before expiration is greater than the global limit", nil)
-		}
+	if len(fieldErrs) > 0 {
+		return response.Error(fieldErrs[0].Status, fieldErrs[0].Message, nil)
 	}
 
 	cmd.OrgID = c.SignedInUser.GetOrgID()
+	createdBy := identity.NewNamespaceIDString(c.SignedInUser.GetNamespacedID()).String()
+	cmd.CreatedBy = &createdBy
 
 	newKeyInfo, err := apikeygen.New(cmd.OrgID, cmd.Name)
 	if err != nil {
@@ -161,14 +164,79 @@ func (hs *HTTPServer) AddAPIKey(c *contextmodel.ReqContext) response.Response {
 	}
 
 	result := &dtos.NewApiKeyResult{
-		ID:   key.ID,
-		Name: key.Name,
-		Key:  newKeyInfo.ClientSecret,
+		ID:               key.ID,
+		Name:             key.Name,
+		Key:              newKeyInfo.ClientSecret,
+		CreatedBy:        key.CreatedBy,
+		CreatedAt:        key.Created,
+		ServiceAccountID: key.ServiceAccountId,
 	}
 
 	return response.JSON(http.StatusOK, result)
 }
 
+// apiKeyValidationResult is the response to a validateOnly API key creation.
+type apiKeyValidationResult struct {
+	Message string `json:"message"`
+	// Errors maps the invalid fields of the command to their error.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// apiKeyFieldError is an invalid field of an API key creation.
+type apiKeyFieldError struct {
+	Field   string
+	Status  int
+	Message string
+}
+
+// validateAPIKey validates the creation of an API key and returns its invalid
+// fields, in the order the creation checks them. Both the creation and its
+// validateOnly dry run use it, so that they reject the same commands with the
+// same status.
+func (hs *HTTPServer) validateAPIKey(c *contextmodel.ReqContext, cmd *apikey.AddCommand) ([]apiKeyFieldError, error) {
+	var errs []apiKeyFieldError
+
+	if !cmd.Role.IsValid() {
+		errs = append(errs, apiKeyFieldError{Field: "role", Status: http.StatusBadRequest, Message: "Invalid role specified"})
+	} else if !c.SignedInUser.GetOrgRole().Includes(cmd.Role) {
+		errs = append(errs, apiKeyFieldError{Field: "role", Status: http.StatusForbidden, Message: "Cannot assign a role higher than user's role"})
+	}
+
+	if cmd.SecondsToLive < 0 {
+		errs = append(errs, apiKeyFieldError{Field: "secondsToLive", Status: http.StatusBadRequest, Message: apikey.ErrInvalidExpiration.Error()})
+	} else if hs.Cfg.ApiKeyMaxSecondsToLive != -1 {
+		if cmd.SecondsToLive == 0 {
+			errs = append(errs, apiKeyFieldError{Field: "secondsToLive", Status: http.StatusBadRequest, Message: "Number of seconds before expiration should be set"})
+		} else if cmd.SecondsToLive > hs.Cfg.ApiKeyMaxSecondsToLive {
+			errs = append(errs, apiKeyFieldError{Field: "secondsToLive", Status: http.StatusBadRequest, Message: "Number of seconds before expiration is greater than the global limit"})
+		}
+	}
+
+	existing, err := hs.apiKeyService.GetApiKeyByName(c.Req.Context(), &apikey.GetByNameQuery{KeyName: cmd.Name, OrgID: c.SignedInUser.GetOrgID()})
+	switch {
+	case err == nil && existing != nil:
+		errs = append(errs, apiKeyFieldError{Field: "name", Status: http.StatusConflict, Message: apikey.ErrDuplicate.Error()})
+	case err != nil && !errors.Is(err, apikey.ErrInvalid) && !errors.Is(err, apikey.ErrNotFound):
+		return nil, err
+	}
+
+	return errs, nil
+}
+
+// apiKeyValidationResponse responds to a validateOnly API key creation with
+// the status the creation would fail with, and all of the invalid fields.
+func apiKeyValidationResponse(errs []apiKeyFieldError) response.Response {
+	if len(errs) == 0 {
+		return response.JSON(http.StatusOK, apiKeyValidationResult{Message: "API key is valid"})
+	}
+
+	result := apiKeyValidationResult{Message: "API key is invalid", Errors: map[string]string{}}
+	for _, err := range errs {
+		result.Errors[err.Field] = err.Message
+	}
+	return response.JSON(errs[0].Status, result)
+}
+
 // swagger:parameters getAPIkeys
 type GetAPIkeysParams struct {
 	// Show expired keys
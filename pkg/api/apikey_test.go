@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/apikey/apikeytest"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web/webtest"
+)
+
+func TestAPIKeyAPI_AddAPIKey(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	service := &recordingAPIKeyService{created: created}
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.Cfg = setting.NewCfg()
+		hs.Cfg.ApiKeyMaxSecondsToLive = -1
+		hs.apiKeyService = service
+	})
+
+	t.Run("should record and return the creator of the key", func(t *testing.T) {
+		req := server.NewPostRequest("/api/auth/keys", strings.NewReader(`{"name": "key", "role": "Viewer"}`))
+		req = webtest.RequestWithSignedInUser(req, authedUserWithPermissions(1, 1, []accesscontrol.Permission{{Action: accesscontrol.ActionAPIKeyCreate}}))
+		res, err := server.SendJSON(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var result dtos.NewApiKeyResult
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+		require.NoError(t, res.Body.Close())
+
+		require.NotNil(t, service.cmd)
+		require.NotNil(t, service.cmd.CreatedBy)
+		assert.Equal(t, "user:1", *service.cmd.CreatedBy)
+		require.NotNil(t, result.CreatedBy)
+		assert.Equal(t, "user:1", *result.CreatedBy)
+		assert.True(t, created.Equal(result.CreatedAt))
+		assert.Nil(t, result.ServiceAccountID, "standalone keys aren't backed by a service account")
+	})
+}
+
+func TestAPIKeyAPI_AddAPIKeyValidateOnly(t *testing.T) {
+	tests := []struct {
+		desc           string
+		body           string
+		existingKey    *apikey.APIKey
+		expectedCode   int
+		expectedErrors map[string]string
+	}{
+		{
+			desc:         "should accept a valid key",
+			body:         `{"name": "key", "role": "Viewer", "validateOnly": true}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:           "should reject a negative lifetime",
+			body:           `{"name": "key", "role": "Viewer", "secondsToLive": -1, "validateOnly": true}`,
+			expectedCode:   http.StatusBadRequest,
+			expectedErrors: map[string]string{"secondsToLive": apikey.ErrInvalidExpiration.Error()},
+		},
+		{
+			desc:           "should reject a duplicate name",
+			body:           `{"name": "key", "role": "Viewer", "validateOnly": true}`,
+			existingKey:    &apikey.APIKey{ID: 2, OrgID: 1, Name: "key"},
+			expectedCode:   http.StatusConflict,
+			expectedErrors: map[string]string{"name": apikey.ErrDuplicate.Error()},
+		},
+		{
+			desc:           "should reject a role higher than the user's role",
+			body:           `{"name": "key", "role": "Admin", "validateOnly": true}`,
+			expectedCode:   http.StatusForbidden,
+			expectedErrors: map[string]string{"role": "Cannot assign a role higher than user's role"},
+		},
+		{
+			desc:           "should return the status of the first failed check",
+			body:           `{"name": "key", "role": "Viewer", "secondsToLive": -1, "validateOnly": true}`,
+			existingKey:    &apikey.APIKey{ID: 2, OrgID: 1, Name: "key"},
+			expectedCode:   http.StatusBadRequest,
+			expectedErrors: map[string]string{"secondsToLive": apikey.ErrInvalidExpiration.Error(), "name": apikey.ErrDuplicate.Error()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			service := &recordingAPIKeyService{}
+			service.ExpectedAPIKey = tt.existingKey
+			if tt.existingKey == nil {
+				service.ExpectedError = apikey.ErrInvalid
+			}
+			server := SetupAPITestServer(t, func(hs *HTTPServer) {
+				hs.Cfg = setting.NewCfg()
+				hs.Cfg.ApiKeyMaxSecondsToLive = -1
+				hs.apiKeyService = service
+			})
+
+			req := server.NewPostRequest("/api/auth/keys", strings.NewReader(tt.body))
+			req = webtest.RequestWithSignedInUser(req, authedUserWithPermissions(1, 1, []accesscontrol.Permission{{Action: accesscontrol.ActionAPIKeyCreate}}))
+			res, err := server.SendJSON(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+
+			var result apiKeyValidationResult
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+			assert.Equal(t, tt.expectedErrors, result.Errors)
+			require.NoError(t, res.Body.Close())
+
+			assert.Nil(t, service.cmd, "validateOnly must not create a key")
+		})
+	}
+}
+
+func TestAPIKeyAPI_AddAPIKeyValidation(t *testing.T) {
+	tests := []struct {
+		desc         string
+		body         string
+		existingKey  *apikey.APIKey
+		expectedCode int
+	}{
+		{
+			desc:         "should reject a duplicate name like the dry run",
+			body:         `{"name": "key", "role": "Viewer"}`,
+			existingKey:  &apikey.APIKey{ID: 2, OrgID: 1, Name: "key"},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			desc:         "should reject a negative lifetime like the dry run",
+			body:         `{"name": "key", "role": "Viewer", "secondsToLive": -1}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			desc:         "should reject a role higher than the user's role like the dry run",
+			body:         `{"name": "key", "role": "Admin"}`,
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			service := &recordingAPIKeyService{}
+			service.ExpectedAPIKey = tt.existingKey
+			if tt.existingKey == nil {
+				service.ExpectedError = apikey.ErrInvalid
+			}
+			server := SetupAPITestServer(t, func(hs *HTTPServer) {
+				hs.Cfg = setting.NewCfg()
+				hs.Cfg.ApiKeyMaxSecondsToLive = -1
+				hs.apiKeyService = service
+			})
+
+			req := server.NewPostRequest("/api/auth/keys", strings.NewReader(tt.body))
+			req = webtest.RequestWithSignedInUser(req, authedUserWithPermissions(1, 1, []accesscontrol.Permission{{Action: accesscontrol.ActionAPIKeyCreate}}))
+			res, err := server.SendJSON(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+			require.NoError(t, res.Body.Close())
+
+			assert.Nil(t, service.cmd, "an invalid key must not be created")
+		})
+	}
+}
+
+func TestAPIKeyAPI_GetAPIKeys(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	createdBy := "user:1"
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.Cfg = setting.NewCfg()
+		hs.apiKeyService = &apikeytest.Service{ExpectedAPIKeys: []*apikey.APIKey{
+			{ID: 1, OrgID: 1, Name: "recorded", Role: org.RoleViewer, Created: created, CreatedBy: &createdBy},
+			{ID: 2, OrgID: 1, Name: "historical", Role: org.RoleViewer, Created: created},
+		}}
+	})
+
+	req := server.NewGetRequest("/api/auth/keys")
+	req = webtest.RequestWithSignedInUser(req, authedUserWithPermissions(1, 1, []accesscontrol.Permission{{Action: accesscontrol.ActionAPIKeyRead}}))
+	res, err := server.Send(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var result []map[string]any
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+	require.NoError(t, res.Body.Close())
+
+	require.Len(t, result, 2)
+	assert.Equal(t, "user:1", result[0]["createdBy"])
+	assert.Equal(t, "2024-01-02T03:04:05Z", result[0]["createdAt"])
+	require.Contains(t, result[1], "createdBy")
+	assert.Nil(t, result[1]["createdBy"])
+	assert.Equal(t, "2024-01-02T03:04:05Z", result[1]["createdAt"])
+}
+
+// recordingAPIKeyService records the command API keys are added with.
+type recordingAPIKeyService struct {
+	apikeytest.Service
+	cmd     *apikey.AddCommand
+	created time.Time
+}
+
+func (s *recordingAPIKeyService) AddAPIKey(_ context.Context, cmd *apikey.AddCommand) (*apikey.APIKey, error) {
+	s.cmd = cmd
+	return &apikey.APIKey{ID: 1, OrgID: cmd.OrgID, Name: cmd.Name, Role: cmd.Role, Created: s.created, CreatedBy: cmd.CreatedBy}, nil
+}
@@ -15,6 +15,14 @@ type NewApiKeyResult struct {
 	Name string `json:"name"`
 	// example: glsa_yscW25imSKJIuav8zF37RZmnbiDvB05G_fcaaf58a
 	Key string `json:"key"`
+	// CreatedBy is the identity that created the key.
+	// example: user:1
+	CreatedBy *string   `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	// ServiceAccountID is the service account the key belongs to, or null
+	// for standalone API keys.
+	// example: 1
+	ServiceAccountID *int64 `json:"serviceAccountId"`
 }
 
 type ApiKeyDTO struct {
@@ -24,4 +32,8 @@ type ApiKeyDTO struct {
 	Expiration    *time.Time             `json:"expiration,omitempty"`
 	LastUsedAt    *time.Time             `json:"lastUsedAt,omitempty"`
 	AccessControl accesscontrol.Metadata `json:"accessControl,omitempty"`
+	// CreatedBy is the identity that created the key, or null for keys
+	// created before it was recorded.
+	CreatedBy *string   `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
 }
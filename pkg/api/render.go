@@ -1,28 +1,195 @@
 package api
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"image"
+	"io"
+	"math"
 	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"golang.org/x/text/language"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/org"
+	pref "github.com/grafana/grafana/pkg/services/preference"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 )
 
+// maxRenderDataPoints is the upper bound accepted for the maxDataPoints render parameter.
+const maxRenderDataPoints = 100000
+
+// renderLegendModes are the accepted values of the legend render parameter.
+var renderLegendModes = map[string]bool{"hidden": true, "bottom": true, "right": true}
+
 func (hs *HTTPServer) RenderHandler(c *contextmodel.ReqContext) {
+	req, ok := hs.getRenderRequest(c)
+	if !ok {
+		return
+	}
+
+	if c.Req.Header.Get("Accept") == "text/event-stream" {
+		if param := getUnsupportedRenderEventsParam(req); param != "" {
+			c.JsonApiErr(http.StatusBadRequest, fmt.Sprintf("Render parameters error: %s isn't supported with Accept: text/event-stream", param), nil)
+			return
+		}
+		hs.renderEvents(c, req)
+		return
+	}
+
+	if req.Diagnostics {
+		hs.renderDiagnostics(c, req)
+		return
+	}
+
+	if req.NoDataPlaceholder {
+		noData, ok := hs.renderHasNoData(c, req)
+		if !ok {
+			return
+		}
+		if noData {
+			hs.renderNoDataPlaceholder(c, req)
+			return
+		}
+	}
+
+	// the render is canceled along with the request if the client disconnects
+	result, err := hs.RenderService.Render(c.Req.Context(), req.Type, req.Opts, nil)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			response.ErrOrFallback(http.StatusInternalServerError, "Rendering failed.", err).WriteTo(c)
+			return
+		}
+		if errors.Is(err, rendering.ErrTimeout) {
+			hs.handleRenderError(c, req, err.Error(), err)
+			return
+		}
+
+		hs.handleRenderError(c, req, "Rendering failed.", err)
+		return
+	}
+
+	result.FilePath, err = processRenderImage(result.FilePath, req.Image)
+	if err != nil {
+		hs.handleRenderError(c, req, "Rendering failed.", err)
+		return
+	}
+
+	if len(req.Sizes) > 0 {
+		hs.renderSizes(c, req, result)
+		return
+	}
+
+	if req.Image.MaxWidth > 0 || req.Image.MaxHeight > 0 {
+		size, err := readPNGSize(result.FilePath)
+		if err != nil {
+			hs.handleRenderError(c, req, "Rendering failed.", err)
+			return
+		}
+		c.Resp.Header().Set("X-Render-Width", strconv.Itoa(size.X))
+		c.Resp.Header().Set("X-Render-Height", strconv.Itoa(size.Y))
+	}
+
+	if req.Format == renderFormatJSON {
+		hs.renderJSON(c, req, result)
+		return
+	}
+
+	c.Resp.Header().Set("Content-Type", renderContentType(req.Type))
+	c.Resp.Header().Set("Cache-Control", "private")
+	http.ServeFile(c.Resp, c.Req, result.FilePath)
+}
+
+// handleRenderError responds to a failed render.
+func (hs *HTTPServer) handleRenderError(c *contextmodel.ReqContext, req renderRequest, title string, err error) {
+	c.Handle(hs.Cfg, http.StatusInternalServerError, hs.renderErrorTitle(c.SignedInUser, req, title), err)
+}
+
+// renderErrorTitle returns the title of a render error. With
+// debug_render_errors enabled, Grafana admins are also shown the path that
+// was attempted.
+func (hs *HTTPServer) renderErrorTitle(user identity.Requester, req renderRequest, title string) string {
+	if hs.Cfg.RendererDebugErrors && user.GetIsGrafanaAdmin() {
+		return fmt.Sprintf("%s Attempted path: %s", title, req.Opts.Path)
+	}
+	return title
+}
+
+const renderFormatJSON = "json"
+
+// maxRenderAnnotationLegendItems bounds the number of annotations listed in a render's annotation legend.
+const maxRenderAnnotationLegendItems = 20
+
+// renderRequest is a parsed request to the render endpoint.
+type renderRequest struct {
+	Type rendering.RenderType
+	Opts rendering.Opts
+	// Format is either empty to respond with the rendered file itself, or
+	// renderFormatJSON to wrap it in a JSON envelope.
+	Format string
+	// IncludeAnnotationLegend lists the dashboard's annotations in the time
+	// range alongside the image in the JSON envelope.
+	IncludeAnnotationLegend bool
+	// IncludeData adds the query results of the rendered panel to the JSON
+	// envelope. Only supported for single-panel renders.
+	IncludeData bool
+	PanelID     int64
+	// Image holds the post-processing applied to rendered PNGs.
+	Image renderImageOpts
+	// Diagnostics returns a description of how the render went instead of
+	// the rendered image. Only available to Grafana admins.
+	Diagnostics bool
+	// NoDataPlaceholder responds with a "no data" placeholder image instead
+	// of rendering if none of the rendered panels have data. Checking the
+	// data queries the panels once more than the render itself.
+	NoDataPlaceholder bool
+	// Sizes, if set, are the bounding boxes of the images to respond with.
+	// The render is made once at the largest of them and downscaled to each.
+	Sizes []image.Point
+}
+
+// renderOrgIDQueryParam is the query parameter Grafana admins pass the
+// organization to render in.
+const renderOrgIDQueryParam = "renderOrgId"
+
+// getRenderRequest reads the render request from the query. If the request
+// is invalid, an error response is written and ok is false.
+func (hs *HTTPServer) getRenderRequest(c *contextmodel.ReqContext) (req renderRequest, ok bool) {
 	queryReader, err := util.NewURLQueryReader(c.Req.URL)
 	if err != nil {
 		c.Handle(hs.Cfg, http.StatusBadRequest, "Render parameters error", err)
-		return
+		return req, false
 	}
 
 	queryParams := fmt.Sprintf("?%s", c.Req.URL.RawQuery)
+	if c.Query(renderTokenQueryParam) != "" {
+		// the render token is consumed, don't pass it on to the renderer
+		query := c.Req.URL.Query()
+		query.Del(renderTokenQueryParam)
+		queryParams = fmt.Sprintf("?%s", query.Encode())
+	}
 
 	width := c.QueryInt("width")
 	if width == 0 {
@@ -37,7 +204,7 @@ func (hs *HTTPServer) RenderHandler(c *contextmodel.ReqContext) {
 	timeout, err := strconv.Atoi(queryReader.Get("timeout", "60"))
 	if err != nil {
 		c.Handle(hs.Cfg, http.StatusBadRequest, "Render parameters error", fmt.Errorf("cannot parse timeout as int: %s", err))
-		return
+		return req, false
 	}
 
 	scale := c.QueryFloat64("scale")
@@ -45,6 +212,38 @@ func (hs *HTTPServer) RenderHandler(c *contextmodel.ReqContext) {
 		scale = hs.Cfg.RendererDefaultImageScale
 	}
 
+	var maxDataPoints int
+	if value := queryReader.Get("maxDataPoints", ""); value != "" {
+		maxDataPoints, err = strconv.Atoi(value)
+		if err != nil || maxDataPoints <= 0 || maxDataPoints > maxRenderDataPoints {
+			c.JsonApiErr(http.StatusBadRequest, fmt.Sprintf("Render parameters error: maxDataPoints must be an integer between 1 and %d", maxRenderDataPoints), nil)
+			return req, false
+		}
+	}
+
+	// the interval is a min interval, see rendering.Opts.Interval
+	interval := queryReader.Get("interval", "")
+	if interval != "" {
+		if duration, err := gtime.ParseDuration(interval); err != nil || duration <= 0 {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: interval must be a positive duration, such as 30s or 5m", nil)
+			return req, false
+		}
+	}
+
+	legend := queryReader.Get("legend", "")
+	if legend != "" && !renderLegendModes[legend] {
+		c.JsonApiErr(http.StatusBadRequest, "Render parameters error: legend can only be hidden, bottom or right", nil)
+		return req, false
+	}
+
+	backgroundColor := queryReader.Get("bgColor", "")
+	if backgroundColor != "" {
+		if _, err := parseRenderColor(backgroundColor); err != nil {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: bgColor must be a hex or named color", nil)
+			return req, false
+		}
+	}
+
 	theme := c.QueryStrings("theme")
 	var themeModel models.Theme
 	if len(theme) > 0 {
@@ -52,67 +251,850 @@ func (hs *HTTPServer) RenderHandler(c *contextmodel.ReqContext) {
 		_, err := models.ParseTheme(themeStr)
 		if err != nil {
 			c.Handle(hs.Cfg, http.StatusBadRequest, "Render parameters error: theme can only be light or dark", err)
-			return
+			return req, false
 		}
 		themeModel = models.Theme(themeStr)
 	} else {
 		themeModel = models.ThemeDark
 	}
 
-	headers := http.Header{}
-	acceptLanguageHeader := c.Req.Header.Values("Accept-Language")
-	if len(acceptLanguageHeader) > 0 {
-		headers["Accept-Language"] = acceptLanguageHeader
-	}
-
 	userID, errID := identity.UserIdentifier(c.SignedInUser.GetNamespacedID())
 	if errID != nil {
 		hs.log.Error("Failed to parse user id", "err", errID)
 	}
 
+	disableAnimations, err := strconv.ParseBool(queryReader.Get("disableAnimations", "true"))
+	if err != nil {
+		c.JsonApiErr(http.StatusBadRequest, "Render parameters error: disableAnimations must be true or false", nil)
+		return req, false
+	}
+
 	encoding := queryReader.Get("encoding", "")
 
-	renderType := rendering.RenderPNG
+	req.Type = rendering.RenderPNG
 	if encoding == "pdf" {
-		renderType = rendering.RenderPDF
+		req.Type = rendering.RenderPDF
 	}
 
-	result, err := hs.RenderService.Render(c.Req.Context(), renderType, rendering.Opts{
+	req.Format = queryReader.Get("format", "")
+	if req.Format != "" && req.Format != renderFormatJSON {
+		c.JsonApiErr(http.StatusBadRequest, "Render parameters error: format can only be json", nil)
+		return req, false
+	}
+
+	req.IncludeAnnotationLegend = c.QueryBool("includeAnnotationLegend")
+	if req.IncludeAnnotationLegend && req.Format != renderFormatJSON {
+		c.JsonApiErr(http.StatusBadRequest, "Render parameters error: includeAnnotationLegend requires format=json", nil)
+		return req, false
+	}
+
+	req.IncludeData = c.QueryBool("includeData")
+	if req.IncludeData {
+		if req.Format != renderFormatJSON {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: includeData requires format=json", nil)
+			return req, false
+		}
+
+		req.PanelID = parseRenderPanelID(c.Query("panelId"))
+		if !strings.HasPrefix(strings.TrimPrefix(web.Params(c.Req)["*"], "/"), "d-solo/") || req.PanelID == 0 {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: includeData is only supported for single-panel renders", nil)
+			return req, false
+		}
+	}
+
+	req.NoDataPlaceholder, err = strconv.ParseBool(queryReader.Get("noDataPlaceholder", "false"))
+	if err != nil {
+		c.JsonApiErr(http.StatusBadRequest, "Render parameters error: noDataPlaceholder must be true or false", nil)
+		return req, false
+	}
+	if req.NoDataPlaceholder {
+		if getRenderDashboardUID(web.Params(c.Req)["*"]) == "" || req.Type != rendering.RenderPNG {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: noDataPlaceholder is only supported for PNG dashboard renders", nil)
+			return req, false
+		}
+		if strings.HasPrefix(strings.TrimPrefix(web.Params(c.Req)["*"], "/"), "d-solo/") {
+			req.PanelID = parseRenderPanelID(c.Query("panelId"))
+			if req.PanelID == 0 {
+				c.JsonApiErr(http.StatusBadRequest, "Render parameters error: noDataPlaceholder requires a panelId for single-panel renders", nil)
+				return req, false
+			}
+		}
+	}
+
+	req.Image.Fit = queryReader.Get("fit", "")
+	if req.Image.Fit != "" {
+		if req.Image.Fit != renderFitContain {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: fit can only be contain", nil)
+			return req, false
+		}
+		if req.Type != rendering.RenderPNG {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: fit is only supported for PNG renders", nil)
+			return req, false
+		}
+
+		background, err := parseRenderColor(queryReader.Get("fitBackground", "transparent"))
+		if err != nil {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: fitBackground must be a hex or named color", nil)
+			return req, false
+		}
+		req.Image.Width, req.Image.Height, req.Image.Background = width, height, background
+	}
+
+	if value := queryReader.Get("compression", ""); value != "" {
+		compression, err := strconv.Atoi(value)
+		if err != nil || compression < 0 || compression > 9 {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: compression must be an integer between 0 and 9", nil)
+			return req, false
+		}
+		if req.Type != rendering.RenderPNG {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: compression is only supported for PNG renders", nil)
+			return req, false
+		}
+		req.Image.Compression = &compression
+	}
+
+	req.Image.Grayscale, err = strconv.ParseBool(queryReader.Get("grayscale", "false"))
+	if err != nil {
+		c.JsonApiErr(http.StatusBadRequest, "Render parameters error: grayscale must be true or false", nil)
+		return req, false
+	}
+	if req.Image.Grayscale && req.Type != rendering.RenderPNG {
+		c.JsonApiErr(http.StatusBadRequest, "Render parameters error: grayscale is only supported for PNG renders", nil)
+		return req, false
+	}
+
+	for _, bound := range []struct {
+		name  string
+		value *int
+	}{{"maxWidth", &req.Image.MaxWidth}, {"maxHeight", &req.Image.MaxHeight}} {
+		value := queryReader.Get(bound.name, "")
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JsonApiErr(http.StatusBadRequest, fmt.Sprintf("Render parameters error: %s must be a positive integer", bound.name), nil)
+			return req, false
+		}
+		if c.Query("width") != "" || c.Query("height") != "" {
+			c.JsonApiErr(http.StatusBadRequest, fmt.Sprintf("Render parameters error: %s can't be combined with width or height", bound.name), nil)
+			return req, false
+		}
+		if req.Type != rendering.RenderPNG {
+			c.JsonApiErr(http.StatusBadRequest, fmt.Sprintf("Render parameters error: %s is only supported for PNG renders", bound.name), nil)
+			return req, false
+		}
+		*bound.value = parsed
+	}
+
+	if value := queryReader.Get("sizes", ""); value != "" {
+		if req.Sizes, err = parseRenderSizes(value); err != nil {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: sizes "+err.Error(), nil)
+			return req, false
+		}
+		if c.Query("width") != "" || c.Query("height") != "" || req.Image.MaxWidth > 0 || req.Image.MaxHeight > 0 || req.Image.Fit != "" {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: sizes can't be combined with width, height, maxWidth, maxHeight or fit", nil)
+			return req, false
+		}
+		if req.Type != rendering.RenderPNG || req.Format != "" {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: sizes is only supported for PNG renders without a format", nil)
+			return req, false
+		}
+		width, height = 0, 0
+		for _, size := range req.Sizes {
+			width, height = max(width, size.X), max(height, size.Y)
+		}
+	}
+
+	fullPage, err := strconv.ParseBool(queryReader.Get("fullPage", "false"))
+	if err != nil {
+		c.JsonApiErr(http.StatusBadRequest, "Render parameters error: fullPage must be true or false", nil)
+		return req, false
+	}
+	if fullPage {
+		if c.Query("height") != "" || req.Image.Fit != "" || len(req.Sizes) > 0 {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: fullPage can't be combined with height, fit or sizes", nil)
+			return req, false
+		}
+		if err := hs.RenderService.IsCapabilitySupported(c.Req.Context(), rendering.FullHeightImages); err != nil {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: fullPage isn't supported by the image renderer", err)
+			return req, false
+		}
+		// the image renderer captures the full height of the page for a height of -1
+		height = -1
+	}
+
+	fieldOverrides := []byte(queryReader.Get("fieldOverrides", ""))
+	if c.Req.Method == http.MethodPost {
+		body, err := readRenderRequestBody(c.Req.Body)
+		if err != nil {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: the body must be a JSON object with fieldOverrides", nil)
+			return req, false
+		}
+		if len(body.FieldOverrides) > 0 {
+			if len(fieldOverrides) > 0 {
+				c.JsonApiErr(http.StatusBadRequest, "Render parameters error: fieldOverrides can't be set in both the query and the body", nil)
+				return req, false
+			}
+			fieldOverrides = body.FieldOverrides
+		}
+	}
+	var fieldOverridesJSON string
+	if len(fieldOverrides) > 0 {
+		if fieldOverridesJSON, err = parseRenderFieldOverrides(fieldOverrides); err != nil {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: fieldOverrides "+err.Error(), nil)
+			return req, false
+		}
+	}
+
+	req.Diagnostics, err = strconv.ParseBool(queryReader.Get("diagnostics", "false"))
+	if err != nil {
+		c.JsonApiErr(http.StatusBadRequest, "Render parameters error: diagnostics must be true or false", nil)
+		return req, false
+	}
+	if req.Diagnostics && !c.SignedInUser.GetIsGrafanaAdmin() {
+		c.JsonApiErr(http.StatusForbidden, "Render diagnostics require Grafana admin privileges", nil)
+		return req, false
+	}
+
+	// Grafana admins can render in another organization than their current
+	// one. The organization isn't read from orgId, which the OrgRedirect
+	// middleware handles first by switching the user's current organization.
+	orgID, orgRole := c.SignedInUser.GetOrgID(), c.SignedInUser.GetOrgRole()
+	var requestedOrgID int64
+	if value := queryReader.Get(renderOrgIDQueryParam, ""); value != "" {
+		requestedOrgID, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || requestedOrgID <= 0 {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: renderOrgId must be a positive integer", nil)
+			return req, false
+		}
+	}
+	if requestedOrgID != 0 && requestedOrgID != orgID {
+		if !c.SignedInUser.GetIsGrafanaAdmin() {
+			c.JsonApiErr(http.StatusForbidden, "Rendering in another organization requires Grafana admin privileges", nil)
+			return req, false
+		}
+		if req.IncludeAnnotationLegend || req.IncludeData || req.NoDataPlaceholder {
+			c.JsonApiErr(http.StatusBadRequest, "Render parameters error: includeAnnotationLegend, includeData and noDataPlaceholder aren't supported in another organization", nil)
+			return req, false
+		}
+		if _, err := hs.orgService.GetByID(c.Req.Context(), &org.GetOrgByIDQuery{ID: requestedOrgID}); err != nil {
+			if errors.Is(err, org.ErrOrgNotFound) {
+				c.JsonApiErr(http.StatusNotFound, "Organization not found", err)
+				return req, false
+			}
+			c.JsonApiErr(http.StatusInternalServerError, "Failed to get organization", err)
+			return req, false
+		}
+		// Grafana admins aren't necessarily members of the organization, so
+		// they render as one of its admins to see all of its dashboards. This
+		// grants nothing more than the admin API, which lets Grafana admins
+		// add themselves to any organization as an admin.
+		orgID, orgRole = requestedOrgID, org.RoleAdmin
+
+		// the rendered page is loaded in the requested organization, so its
+		// orgId must not make OrgRedirect switch organizations again
+		query, err := url.ParseQuery(strings.TrimPrefix(queryParams, "?"))
+		if err != nil {
+			c.Handle(hs.Cfg, http.StatusBadRequest, "Render parameters error", err)
+			return req, false
+		}
+		query.Del(renderOrgIDQueryParam)
+		query.Set("orgId", strconv.FormatInt(orgID, 10))
+		queryParams = fmt.Sprintf("?%s", query.Encode())
+	}
+
+	var serverURL string
+	if len(hs.Cfg.RendererTagServerUrls) > 0 {
+		if dashboardUID := getRenderDashboardUID(web.Params(c.Req)["*"]); dashboardUID != "" {
+			dashboard, err := hs.DashboardService.GetDashboard(c.Req.Context(), &dashboards.GetDashboardQuery{UID: dashboardUID, OrgID: orgID})
+			if err != nil {
+				if errors.Is(err, dashboards.ErrDashboardNotFound) {
+					c.JsonApiErr(http.StatusNotFound, "Dashboard not found", err)
+					return req, false
+				}
+				c.JsonApiErr(http.StatusInternalServerError, "Failed to get dashboard", err)
+				return req, false
+			}
+			serverURL = getRendererServerURLForTags(hs.Cfg.RendererTagServerUrls, dashboard.GetTags())
+		}
+	}
+
+	headers := http.Header{}
+	acceptLanguage := sanitizeAcceptLanguage(c.Req.Header.Values("Accept-Language"))
+	if acceptLanguage = mergeAcceptLanguage(acceptLanguage, hs.getOrgLanguage(c.Req.Context(), orgID)); acceptLanguage != "" {
+		headers.Set("Accept-Language", acceptLanguage)
+	}
+
+	req.Opts = rendering.Opts{
 		CommonOpts: rendering.CommonOpts{
 			TimeoutOpts: rendering.TimeoutOpts{
 				Timeout: time.Duration(timeout) * time.Second,
 			},
 			AuthOpts: rendering.AuthOpts{
-				OrgID:   c.SignedInUser.GetOrgID(),
+				OrgID:   orgID,
 				UserID:  userID,
-				OrgRole: c.SignedInUser.GetOrgRole(),
+				OrgRole: orgRole,
 			},
 			Path:            web.Params(c.Req)["*"] + queryParams,
 			Timezone:        queryReader.Get("tz", ""),
 			ConcurrentLimit: hs.Cfg.RendererConcurrentRequestLimit,
 			Headers:         headers,
+			MetricsLabel:    hs.getRenderMetricsLabel(c),
 		},
 		Width:             width,
 		Height:            height,
 		DeviceScaleFactor: scale,
 		Theme:             themeModel,
-	}, nil)
+		MaxDataPoints:     maxDataPoints,
+		Interval:          interval,
+		Legend:            legend,
+		BackgroundColor:   backgroundColor,
+		FieldOverrides:    fieldOverridesJSON,
+		ServerURL:         serverURL,
+		DisableAnimations: disableAnimations,
+	}
+
+	return req, true
+}
+
+// getRenderMetricsLabel returns the X-Render-Label header of the request if
+// it's one of the configured metrics labels, or an empty string otherwise.
+func (hs *HTTPServer) getRenderMetricsLabel(c *contextmodel.ReqContext) string {
+	label := c.Req.Header.Get("X-Render-Label")
+	if label == "" {
+		return ""
+	}
+	if !slices.Contains(hs.Cfg.RendererMetricsLabels, label) {
+		hs.log.Debug("Ignoring render label that isn't in metrics_labels", "label", label)
+		return ""
+	}
+	return label
+}
+
+// getRendererServerURLForTags returns the renderer configured for the first
+// of the dashboard tags that has one, or an empty string to use the default renderer.
+func getRendererServerURLForTags(tagServerURLs map[string]string, tags []string) string {
+	for _, tag := range tags {
+		if serverURL, ok := tagServerURLs[tag]; ok {
+			return serverURL
+		}
+	}
+	return ""
+}
+
+// maxAcceptLanguageLength caps the length of an Accept-Language value forwarded to the renderer.
+const maxAcceptLanguageLength = 256
+
+// sanitizeAcceptLanguage returns the well-formed language ranges of the given
+// Accept-Language header values as a single header value. Oversized values and
+// ranges that aren't valid BCP 47 tags are dropped.
+func sanitizeAcceptLanguage(values []string) string {
+	var ranges []string
+	for _, value := range values {
+		if len(value) > maxAcceptLanguageLength {
+			continue
+		}
+
+		for _, part := range strings.Split(value, ",") {
+			tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			tag = strings.TrimSpace(tag)
+			if tag != "*" {
+				parsed, err := language.Parse(tag)
+				if err != nil {
+					continue
+				}
+				tag = parsed.String()
+			}
+
+			if params == "" {
+				ranges = append(ranges, tag)
+				continue
+			}
+
+			weight, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+			if !ok {
+				continue
+			}
+			q, err := strconv.ParseFloat(weight, 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+			ranges = append(ranges, tag+";q="+strconv.FormatFloat(q, 'f', -1, 64))
+		}
+	}
+
+	return strings.Join(ranges, ", ")
+}
+
+// getOrgLanguage returns the default language of the org, or an empty string
+// if it has none.
+func (hs *HTTPServer) getOrgLanguage(ctx context.Context, orgID int64) string {
+	prefs, err := hs.preferenceService.Get(ctx, &pref.GetPreferenceQuery{OrgID: orgID})
 	if err != nil {
-		if errors.Is(err, rendering.ErrTimeout) {
-			c.Handle(hs.Cfg, http.StatusInternalServerError, err.Error(), err)
+		hs.log.Warn("Failed to get org preferences", "orgId", orgID, "error", err)
+		return ""
+	}
+	if prefs == nil || prefs.JSONData == nil {
+		return ""
+	}
+	return prefs.JSONData.Language
+}
+
+// mergeAcceptLanguage merges the sanitized Accept-Language of the client with
+// the org's default language into a single preference list. The client's
+// languages come first, ordered by their quality values, followed by the org
+// default with a lower quality than any of them. Each language is listed once.
+func mergeAcceptLanguage(acceptLanguage string, orgLanguage string) string {
+	type languageRange struct {
+		tag string
+		q   float64
+	}
+
+	var ranges []languageRange
+	seen := map[string]int{}
+	add := func(tag string, q float64) {
+		key := strings.ToLower(tag)
+		if i, ok := seen[key]; ok {
+			ranges[i].q = max(ranges[i].q, q)
 			return
 		}
+		seen[key] = len(ranges)
+		ranges = append(ranges, languageRange{tag: tag, q: q})
+	}
+
+	if acceptLanguage != "" {
+		for _, part := range strings.Split(acceptLanguage, ",") {
+			tag, weight, _ := strings.Cut(strings.TrimSpace(part), ";q=")
+			q := 1.0
+			if weight != "" {
+				// the value is sanitized, so the weight is known to parse
+				q, _ = strconv.ParseFloat(weight, 64)
+			}
+			add(tag, q)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	if orgLanguage != "" {
+		if tag, err := language.Parse(orgLanguage); err == nil {
+			if _, ok := seen[strings.ToLower(tag.String())]; !ok {
+				q := 1.0
+				if len(ranges) > 0 {
+					lowest := ranges[len(ranges)-1].q
+					if lowest > 0.1 {
+						q = lowest - 0.1
+					} else {
+						q = lowest / 2
+					}
+					q = max(0.001, math.Round(q*1000)/1000)
+				}
+				add(tag.String(), q)
+			}
+		}
+	}
+
+	values := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		if r.q == 1 {
+			values = append(values, r.tag)
+			continue
+		}
+		values = append(values, r.tag+";q="+strconv.FormatFloat(r.q, 'f', -1, 64))
+	}
+	return strings.Join(values, ", ")
+}
+
+// renderJSONResult is the JSON envelope returned for renders with format=json.
+type renderJSONResult struct {
+	ContentType string `json:"contentType"`
+	// Image is the base64 encoded render result.
+	Image       string                  `json:"image"`
+	Annotations []renderAnnotationEntry `json:"annotations,omitempty"`
+	// Data holds the query results of the rendered panel.
+	Data *backend.QueryDataResponse `json:"data,omitempty"`
+}
 
-		c.Handle(hs.Cfg, http.StatusInternalServerError, "Rendering failed.", err)
+// renderAnnotationEntry is an annotation listed in the annotation legend of a render.
+type renderAnnotationEntry struct {
+	Time    int64    `json:"time"`
+	TimeEnd int64    `json:"timeEnd,omitempty"`
+	Text    string   `json:"text"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+func (hs *HTTPServer) renderJSON(c *contextmodel.ReqContext, req renderRequest, result *rendering.RenderResult) {
+	content, err := os.ReadFile(result.FilePath)
+	if err != nil {
+		c.JsonApiErr(http.StatusInternalServerError, "Failed to read render result", err)
 		return
 	}
 
-	if encoding == "pdf" {
-		c.Resp.Header().Set("Content-Type", "application/pdf")
-	} else {
-		c.Resp.Header().Set("Content-Type", "image/png")
+	body := renderJSONResult{
+		ContentType: renderContentType(req.Type),
+		Image:       base64.StdEncoding.EncodeToString(content),
+	}
+
+	if req.IncludeAnnotationLegend || req.IncludeData {
+		dashboard, errResp := hs.getRenderDashboard(c, getRenderDashboardUID(web.Params(c.Req)["*"]))
+		if errResp != nil {
+			errResp.WriteTo(c)
+			return
+		}
+
+		if req.IncludeAnnotationLegend {
+			body.Annotations, err = hs.getRenderAnnotations(c, dashboard)
+			if err != nil {
+				c.JsonApiErr(http.StatusInternalServerError, "Failed to get annotations", err)
+				return
+			}
+		}
+
+		if req.IncludeData {
+			body.Data, err = hs.getRenderPanelData(c, req, dashboard, req.PanelID)
+			if err != nil {
+				if errors.Is(err, errRenderPanelNotFound) {
+					c.JsonApiErr(http.StatusNotFound, "Panel not found", err)
+					return
+				}
+				var variable errRenderPanelVariable
+				if errors.As(err, &variable) {
+					c.JsonApiErr(http.StatusBadRequest, fmt.Sprintf("Render parameters error: includeData isn't supported for panels whose queries use template variables, %s", variable.Error()), nil)
+					return
+				}
+				hs.handleQueryMetricsError(err).WriteTo(c)
+				return
+			}
+		}
 	}
 
 	c.Resp.Header().Set("Cache-Control", "private")
-	http.ServeFile(c.Resp, c.Req, result.FilePath)
+	c.JSON(http.StatusOK, body)
+}
+
+// getRenderDashboard returns the dashboard being rendered, or an error
+// response if it can't be resolved or the user can't view it.
+func (hs *HTTPServer) getRenderDashboard(c *contextmodel.ReqContext, dashboardUID string) (*dashboards.Dashboard, response.Response) {
+	if dashboardUID == "" {
+		return nil, response.Error(http.StatusBadRequest, "Render parameters error: not a dashboard render", nil)
+	}
+
+	dashboard, err := hs.DashboardService.GetDashboard(c.Req.Context(), &dashboards.GetDashboardQuery{UID: dashboardUID, OrgID: c.SignedInUser.GetOrgID()})
+	if err != nil {
+		if errors.Is(err, dashboards.ErrDashboardNotFound) {
+			return nil, response.Error(http.StatusNotFound, "Dashboard not found", err)
+		}
+		return nil, response.Error(http.StatusInternalServerError, "Failed to get dashboard", err)
+	}
+
+	g, err := guardian.NewByDashboard(c.Req.Context(), dashboard, c.SignedInUser.GetOrgID(), c.SignedInUser)
+	if err != nil {
+		return nil, response.Error(http.StatusInternalServerError, "Error while checking dashboard permissions", err)
+	}
+	if canView, err := g.CanView(); err != nil || !canView {
+		return nil, dashboardGuardianResponse(err)
+	}
+
+	return dashboard, nil
+}
+
+func (hs *HTTPServer) getRenderAnnotations(c *contextmodel.ReqContext, dashboard *dashboards.Dashboard) ([]renderAnnotationEntry, error) {
+	timeRange := gtime.NewTimeRange(c.Query("from"), c.Query("to"))
+	items, err := hs.annotationsRepo.Find(c.Req.Context(), &annotations.ItemQuery{
+		OrgID:        c.SignedInUser.GetOrgID(),
+		DashboardID:  dashboard.ID,
+		DashboardUID: dashboard.UID,
+		From:         timeRange.GetFromAsMsEpoch(),
+		To:           timeRange.GetToAsMsEpoch(),
+		Limit:        maxRenderAnnotationLegendItems,
+		SignedInUser: c.SignedInUser,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]renderAnnotationEntry, 0, len(items))
+	for _, item := range items {
+		if len(entries) == maxRenderAnnotationLegendItems {
+			break
+		}
+		entries = append(entries, renderAnnotationEntry{
+			Time:    item.Time,
+			TimeEnd: item.TimeEnd,
+			Text:    item.Text,
+			Tags:    item.Tags,
+		})
+	}
+
+	return entries, nil
+}
+
+var errRenderPanelNotFound = errors.New("panel not found")
+
+// getRenderPanelData runs the queries of a dashboard panel the way the
+// frontend does when rendering it: with the panel's relative time and time
+// shift applied, and the max data points and interval of the panel in the
+// render. Panels whose queries use template variables return an
+// errRenderPanelVariable, as only the frontend interpolates them.
+func (hs *HTTPServer) getRenderPanelData(c *contextmodel.ReqContext, req renderRequest, dashboard *dashboards.Dashboard, panelID int64) (*backend.QueryDataResponse, error) {
+	panel := findRenderPanel(dashboard.Data.Get("panels").MustArray(), panelID)
+	if panel == nil {
+		return nil, errRenderPanelNotFound
+	}
+
+	if name := getRenderPanelVariable(dashboard.Data, panel); name != "" {
+		return nil, errRenderPanelVariable{Name: name}
+	}
+
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" {
+		from = dashboard.Data.GetPath("time", "from").MustString("now-6h")
+	}
+	if to == "" {
+		to = dashboard.Data.GetPath("time", "to").MustString("now")
+	}
+	from, to, err := getRenderPanelTimeRange(panel, from, to)
+	if err != nil {
+		return nil, err
+	}
+	maxDataPoints, interval := getRenderPanelResolution(req, panel, gtime.NewTimeRange(from, to))
+
+	var queries []*simplejson.Json
+	for _, target := range panel.Get("targets").MustArray() {
+		original, _ := target.(map[string]any)
+		if hide, _ := original["hide"].(bool); hide {
+			continue
+		}
+
+		// the query is copied to leave the dashboard as it is
+		query := simplejson.New()
+		for key, value := range original {
+			query.Set(key, value)
+		}
+
+		// queries without a data source use the one of the panel
+		if _, ok := query.CheckGet("datasource"); !ok {
+			query.Set("datasource", panel.Get("datasource").Interface())
+		}
+		if _, ok := query.CheckGet("maxDataPoints"); !ok {
+			query.Set("maxDataPoints", maxDataPoints)
+		}
+		if _, ok := query.CheckGet("intervalMs"); !ok {
+			query.Set("intervalMs", interval.Milliseconds())
+		}
+		queries = append(queries, query)
+	}
+
+	if len(queries) == 0 {
+		return &backend.QueryDataResponse{Responses: backend.Responses{}}, nil
+	}
+
+	return hs.queryDataService.QueryData(c.Req.Context(), c.SignedInUser, c.SkipDSCache, dtos.MetricRequest{
+		From:    from,
+		To:      to,
+		Queries: queries,
+	})
+}
+
+// getRenderPanelTimeRange returns the time range of a panel's queries: the
+// time range of the render with the panel's relative time and time shift
+// applied.
+func getRenderPanelTimeRange(panel *simplejson.Json, from, to string) (string, string, error) {
+	if timeFrom := panel.Get("timeFrom").MustString(); timeFrom != "" {
+		switch {
+		case strings.HasPrefix(timeFrom, "now"):
+			from, to = timeFrom, timeFrom
+		default:
+			if _, err := gtime.ParseInterval(timeFrom); err != nil {
+				return "", "", fmt.Errorf("invalid relative time %q of panel", timeFrom)
+			}
+			from, to = "now-"+timeFrom, "now"
+		}
+	}
+
+	if timeShift := panel.Get("timeShift").MustString(); timeShift != "" {
+		shift, err := gtime.ParseInterval(timeShift)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid time shift %q of panel", timeShift)
+		}
+		timeRange := gtime.NewTimeRange(from, to)
+		from = strconv.FormatInt(timeRange.GetFromAsTimeUTC().Add(-shift).UnixMilli(), 10)
+		to = strconv.FormatInt(timeRange.GetToAsTimeUTC().Add(-shift).UnixMilli(), 10)
+	}
+
+	return from, to, nil
+}
+
+// getRenderPanelResolution returns the max data points and the interval of
+// a panel's queries. Like in the frontend, the max data points default to the
+// width of the panel, and the interval is the time range spread over the max
+// data points, but no less than the panel's min interval.
+func getRenderPanelResolution(req renderRequest, panel *simplejson.Json, timeRange gtime.TimeRange) (int64, time.Duration) {
+	maxDataPoints := int64(req.Opts.MaxDataPoints)
+	if maxDataPoints == 0 {
+		maxDataPoints = panel.Get("maxDataPoints").MustInt64()
+	}
+	if maxDataPoints == 0 {
+		maxDataPoints = int64(req.Opts.Width)
+		if req.PanelID == 0 {
+			// panels of a dashboard render share its width
+			maxDataPoints = maxDataPoints * panel.GetPath("gridPos", "w").MustInt64(24) / 24
+		}
+	}
+	maxDataPoints = max(maxDataPoints, 1)
+
+	interval := gtime.RoundInterval(timeRange.GetToAsTimeUTC().Sub(timeRange.GetFromAsTimeUTC()) / time.Duration(maxDataPoints))
+
+	minInterval := req.Opts.Interval
+	if minInterval == "" {
+		minInterval = panel.Get("interval").MustString()
+	}
+	if minInterval != "" {
+		if parsed, err := gtime.ParseIntervalStringToTimeDuration(minInterval); err == nil && interval < parsed {
+			interval = parsed
+		}
+	}
+
+	return maxDataPoints, interval
+}
+
+// findRenderPanel returns the panel with the given ID, including panels of
+// collapsed rows, or nil if the dashboard has no such panel.
+func findRenderPanel(panels []any, panelID int64) *simplejson.Json {
+	for _, panelObj := range panels {
+		panel := simplejson.NewFromAny(panelObj)
+		if panel.Get("type").MustString() == "row" && panel.Get("collapsed").MustBool() {
+			if nested := findRenderPanel(panel.Get("panels").MustArray(), panelID); nested != nil {
+				return nested
+			}
+			continue
+		}
+		if panel.Get("id").MustInt64() == panelID {
+			return panel
+		}
+	}
+	return nil
+}
+
+// parseRenderPanelID returns the ID of the panel a render's panelId points
+// to, either as a number or as the panel-<id> key of scenes dashboards, or 0
+// if it's neither.
+func parseRenderPanelID(value string) int64 {
+	panelID, err := strconv.ParseInt(strings.TrimPrefix(value, "panel-"), 10, 64)
+	if err != nil || panelID < 0 {
+		return 0
+	}
+	return panelID
+}
+
+// getRenderDashboardUID returns the UID of the dashboard a render path such
+// as d/<uid>/<slug> or d-solo/<uid>/<slug> points to, or an empty string if
+// the path isn't a dashboard.
+func getRenderDashboardUID(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) < 2 || (segments[0] != "d" && segments[0] != "d-solo") {
+		return ""
+	}
+	return segments[1]
+}
+
+func renderContentType(renderType rendering.RenderType) string {
+	if renderType == rendering.RenderPDF {
+		return "application/pdf"
+	}
+	return "image/png"
+}
+
+// renderEvent is the payload of a server-sent event reporting render progress.
+type renderEvent struct {
+	Phase       string `json:"phase"`
+	Message     string `json:"message,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	// Image is the base64 encoded render result, sent with the completed event.
+	Image string `json:"image,omitempty"`
+}
+
+// getUnsupportedRenderEventsParam returns the first parameter of the request
+// that changes the response in a way server-sent events can't carry, or an
+// empty string if there is none. Events only carry the rendered image.
+func getUnsupportedRenderEventsParam(req renderRequest) string {
+	switch {
+	case len(req.Sizes) > 0:
+		return "sizes"
+	case req.Format != "":
+		return "format"
+	case req.Diagnostics:
+		return "diagnostics"
+	case req.NoDataPlaceholder:
+		return "noDataPlaceholder"
+	}
+	return ""
+}
+
+// renderEvents renders while streaming the progress as server-sent events,
+// ending with either a completed event holding the result or a failed event.
+func (hs *HTTPServer) renderEvents(c *contextmodel.ReqContext, req renderRequest) {
+	c.Resp.Header().Set("Content-Type", "text/event-stream")
+	c.Resp.Header().Set("Cache-Control", "no-cache")
+	c.Resp.WriteHeader(http.StatusOK)
+
+	opts := req.Opts
+	opts.OnProgress = func(phase rendering.RenderPhase) {
+		hs.writeRenderEvent(c, "progress", renderEvent{Phase: string(phase)})
+	}
+
+	result, err := hs.RenderService.Render(c.Req.Context(), req.Type, opts, nil)
+	if err != nil {
+		hs.log.Error("Rendering failed", "error", err)
+		message := "Rendering failed."
+		if errors.Is(err, rendering.ErrTimeout) {
+			message = err.Error()
+		}
+		hs.writeRenderEvent(c, "error", renderEvent{Phase: "failed", Message: message})
+		return
+	}
+
+	filePath, err := processRenderImage(result.FilePath, req.Image)
+	if err != nil {
+		hs.log.Error("Failed to process render result", "error", err)
+		hs.writeRenderEvent(c, "error", renderEvent{Phase: "failed", Message: "Rendering failed."})
+		return
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		hs.log.Error("Failed to read render result", "error", err)
+		hs.writeRenderEvent(c, "error", renderEvent{Phase: "failed", Message: "Rendering failed."})
+		return
+	}
+
+	hs.writeRenderEvent(c, "complete", renderEvent{
+		Phase:       "completed",
+		ContentType: renderContentType(req.Type),
+		Image:       base64.StdEncoding.EncodeToString(content),
+	})
+}
+
+func (hs *HTTPServer) writeRenderEvent(c *contextmodel.ReqContext, name string, event renderEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		hs.log.Error("Failed to marshal render event", "error", err)
+		return
+	}
+
+	if _, err := io.WriteString(c.Resp, fmt.Sprintf("event: %s\ndata: %s\n\n", name, data)); err != nil {
+		hs.log.Debug("Failed to write render event", "error", err)
+		return
+	}
+	c.Resp.Flush()
+}
+
+// GetRenderLoad returns the number of renders in flight against the
+// concurrent render limit and the number of renders rejected at the limit,
+// so that image renderers can be scaled on the render backpressure. It's
+// restricted to Grafana admins, as the load of the instance isn't scoped to
+// an organization.
+func (hs *HTTPServer) GetRenderLoad(c *contextmodel.ReqContext) response.Response {
+	return response.JSON(http.StatusOK, hs.RenderService.LoadStats())
 }
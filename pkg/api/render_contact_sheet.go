@@ -0,0 +1,196 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/api/apierrors"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/dashboards/dashboardaccess"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/search/model"
+	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const (
+	// maxContactSheetDashboards bounds the number of dashboards on a contact sheet.
+	maxContactSheetDashboards = 50
+	// contactSheetConcurrency is the number of dashboards of a contact sheet rendered at the same time.
+	contactSheetConcurrency = 4
+
+	contactSheetRenderWidth  = 1280
+	contactSheetRenderHeight = 720
+	contactSheetTileWidth    = 320
+	contactSheetTileHeight   = 180
+	contactSheetLabelHeight  = 24
+	contactSheetGap          = 8
+	contactSheetColumns      = 4
+	contactSheetMaxLabelLen  = 40
+)
+
+// contactSheetTile is a dashboard on a contact sheet. Image is nil if the
+// dashboard couldn't be rendered.
+type contactSheetTile struct {
+	Title string
+	Image image.Image
+}
+
+// GetFolderContactSheet renders the dashboards of a folder as thumbnails,
+// tiled into a single labeled SVG image. Dashboards that fail to render are
+// shown as placeholder tiles.
+func (hs *HTTPServer) GetFolderContactSheet(c *contextmodel.ReqContext) response.Response {
+	folderUID := web.Params(c.Req)[":uid"]
+	f, err := hs.folderService.Get(c.Req.Context(), &folder.GetFolderQuery{UID: &folderUID, OrgID: c.SignedInUser.GetOrgID(), SignedInUser: c.SignedInUser})
+	if err != nil {
+		return apierrors.ToFolderErrorResponse(err)
+	}
+
+	hits, err := hs.DashboardService.SearchDashboards(c.Req.Context(), &dashboards.FindPersistedDashboardsQuery{
+		OrgId:        c.SignedInUser.GetOrgID(),
+		SignedInUser: c.SignedInUser,
+		FolderUIDs:   []string{f.UID},
+		Type:         searchstore.TypeDashboard,
+		Limit:        maxContactSheetDashboards,
+		Page:         1,
+		Permission:   dashboardaccess.PERMISSION_VIEW,
+	})
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to search dashboards", err)
+	}
+
+	userID, err := identity.UserIdentifier(c.SignedInUser.GetNamespacedID())
+	if err != nil {
+		hs.log.Error("Failed to parse user id", "err", err)
+	}
+	authOpts := rendering.AuthOpts{OrgID: c.SignedInUser.GetOrgID(), UserID: userID, OrgRole: c.SignedInUser.GetOrgRole()}
+
+	tiles := make([]contactSheetTile, len(hits))
+	var g errgroup.Group
+	g.SetLimit(contactSheetConcurrency)
+	for i, hit := range hits {
+		tiles[i].Title = hit.Title
+		g.Go(func() error {
+			img, err := hs.renderContactSheetThumbnail(c.Req.Context(), authOpts, hit)
+			if err != nil {
+				hs.log.Warn("Failed to render contact sheet thumbnail", "dashboardUID", hit.UID, "error", err)
+				return nil
+			}
+			tiles[i].Image = img
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	sheet, err := writeContactSheet(tiles)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to create contact sheet", err)
+	}
+
+	return response.Respond(http.StatusOK, sheet).
+		SetHeader("Content-Type", "image/svg+xml").
+		SetHeader("Cache-Control", "private")
+}
+
+func (hs *HTTPServer) renderContactSheetThumbnail(ctx context.Context, authOpts rendering.AuthOpts, hit *model.Hit) (image.Image, error) {
+	u := url.URL{Path: path.Join("d", hit.UID, hit.Slug)}
+	q := u.Query()
+	q.Set("orgId", strconv.FormatInt(authOpts.OrgID, 10))
+	q.Set("kiosk", "")
+	u.RawQuery = q.Encode()
+
+	result, err := hs.RenderService.Render(ctx, rendering.RenderPNG, rendering.Opts{
+		CommonOpts: rendering.CommonOpts{
+			TimeoutOpts:     rendering.TimeoutOpts{Timeout: 30 * time.Second},
+			AuthOpts:        authOpts,
+			Path:            u.String(),
+			ConcurrentLimit: hs.Cfg.RendererConcurrentRequestLimit,
+		},
+		ErrorOpts: rendering.ErrorOpts{
+			ErrorConcurrentLimitReached: true,
+			ErrorRenderUnavailable:      true,
+		},
+		Width:             contactSheetRenderWidth,
+		Height:            contactSheetRenderHeight,
+		DeviceScaleFactor: 1,
+		DisableAnimations: true,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := readPNG(result.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	return scaleImage(img, contactSheetTileWidth, contactSheetTileHeight), nil
+}
+
+// writeContactSheet tiles the thumbnails into an SVG image, each labeled with
+// the title of its dashboard.
+func writeContactSheet(tiles []contactSheetTile) ([]byte, error) {
+	columns := min(len(tiles), contactSheetColumns)
+	rows := (len(tiles) + contactSheetColumns - 1) / contactSheetColumns
+	cellWidth := contactSheetTileWidth + contactSheetGap
+	cellHeight := contactSheetTileHeight + contactSheetLabelHeight + contactSheetGap
+	width := max(columns*cellWidth+contactSheetGap, contactSheetTileWidth)
+	height := max(rows*cellHeight+contactSheetGap, contactSheetLabelHeight)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%d" height="%d" viewBox="0 0 %d %d">`, width, height, width, height)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="#ffffff"/>`)
+	if len(tiles) == 0 {
+		fmt.Fprintf(&buf, `<text x="%d" y="%d" font-family="sans-serif" font-size="12" fill="#464c54">No dashboards</text>`, contactSheetGap, contactSheetLabelHeight-8)
+	}
+
+	for i, tile := range tiles {
+		x := contactSheetGap + (i%contactSheetColumns)*cellWidth
+		y := contactSheetGap + (i/contactSheetColumns)*cellHeight
+
+		if tile.Image != nil {
+			var img bytes.Buffer
+			if err := png.Encode(&img, tile.Image); err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&buf, `<image x="%d" y="%d" width="%d" height="%d" xlink:href="data:image/png;base64,%s"/>`,
+				x, y, contactSheetTileWidth, contactSheetTileHeight, base64.StdEncoding.EncodeToString(img.Bytes()))
+		} else {
+			fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d" fill="#e0e0e0"/>`, x, y, contactSheetTileWidth, contactSheetTileHeight)
+			fmt.Fprintf(&buf, `<text x="%d" y="%d" text-anchor="middle" font-family="sans-serif" font-size="14" fill="#6e6e6e">Rendering failed</text>`,
+				x+contactSheetTileWidth/2, y+contactSheetTileHeight/2)
+		}
+
+		fmt.Fprintf(&buf, `<text x="%d" y="%d" font-family="sans-serif" font-size="12" fill="#24292e">`, x, y+contactSheetTileHeight+contactSheetLabelHeight-8)
+		if err := xml.EscapeText(&buf, []byte(truncateContactSheetLabel(tile.Title))); err != nil {
+			return nil, err
+		}
+		buf.WriteString(`</text>`)
+	}
+
+	buf.WriteString(`</svg>`)
+	return buf.Bytes(), nil
+}
+
+func truncateContactSheetLabel(title string) string {
+	runes := []rune(title)
+	if len(runes) <= contactSheetMaxLabelLen {
+		return title
+	}
+	return string(runes[:contactSheetMaxLabelLen-1]) + "…"
+}
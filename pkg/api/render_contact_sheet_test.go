@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/folder/foldertest"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/search/model"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestGetFolderContactSheet(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "render.png")
+	img := image.NewNRGBA(image.Rect(0, 0, 1280, 720))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.NRGBA{R: 255, A: 255}), image.Point{}, draw.Src)
+	f, err := os.Create(filePath)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, img))
+	require.NoError(t, f.Close())
+
+	setup := func(t *testing.T, folderService folder.Service, hits model.HitList) (*HTTPServer, *dashboards.FindPersistedDashboardsQuery) {
+		renderService := rendering.NewMockService(gomock.NewController(t))
+		renderService.EXPECT().Render(gomock.Any(), rendering.RenderPNG, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ rendering.RenderType, opts rendering.Opts, _ rendering.Session) (*rendering.RenderResult, error) {
+				if strings.HasPrefix(opts.Path, "d/broken/") {
+					return nil, errors.New("render failed")
+				}
+				return &rendering.RenderResult{FilePath: filePath}, nil
+			}).AnyTimes()
+
+		var query dashboards.FindPersistedDashboardsQuery
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardService.On("SearchDashboards", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				query = *args.Get(1).(*dashboards.FindPersistedDashboardsQuery)
+			}).Return(hits, nil).Maybe()
+
+		return &HTTPServer{
+			Cfg:              setting.NewCfg(),
+			RenderService:    renderService,
+			DashboardService: dashboardService,
+			folderService:    folderService,
+			log:              log.New("test"),
+		}, &query
+	}
+
+	get := func(t *testing.T, hs *HTTPServer, url string) *httptest.ResponseRecorder {
+		scenario := setupScenarioContext(t, url)
+		scenario.m.Get("/api/render/folders/:uid/contact-sheet", routing.Wrap(func(c *contextmodel.ReqContext) response.Response {
+			c.SignedInUser = &user.SignedInUser{OrgID: testOrgID, UserID: testUserID, OrgRole: org.RoleViewer}
+			return hs.GetFolderContactSheet(c)
+		}))
+		scenario.req = httptest.NewRequest(http.MethodGet, url, nil)
+		scenario.resp = httptest.NewRecorder()
+		scenario.exec()
+		return scenario.resp
+	}
+
+	t.Run("should tile the dashboards of the folder", func(t *testing.T) {
+		hs, query := setup(t, &foldertest.FakeService{ExpectedFolder: &folder.Folder{UID: "folder"}}, model.HitList{
+			{UID: "first", Slug: "first", Title: "First & foremost"},
+			{UID: "broken", Slug: "broken", Title: "Broken"},
+		})
+
+		resp := get(t, hs, "/api/render/folders/folder/contact-sheet")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "image/svg+xml", resp.Header().Get("Content-Type"))
+
+		body := resp.Body.String()
+		assert.Equal(t, 1, strings.Count(body, "data:image/png;base64,"))
+		assert.Equal(t, 1, strings.Count(body, "Rendering failed"))
+		assert.Contains(t, body, "First &amp; foremost")
+		assert.Contains(t, body, "Broken")
+
+		assert.Equal(t, []string{"folder"}, query.FolderUIDs)
+		assert.Equal(t, int64(maxContactSheetDashboards), query.Limit)
+	})
+
+	t.Run("should return not found for an unknown folder", func(t *testing.T) {
+		hs, _ := setup(t, &foldertest.FakeService{ExpectedError: dashboards.ErrFolderNotFound}, nil)
+
+		resp := get(t, hs, "/api/render/folders/unknown/contact-sheet")
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestTruncateContactSheetLabel(t *testing.T) {
+	assert.Equal(t, "Short", truncateContactSheetLabel("Short"))
+
+	truncated := truncateContactSheetLabel(strings.Repeat("é", contactSheetMaxLabelLen+5))
+	assert.Equal(t, contactSheetMaxLabelLen, len([]rune(truncated)))
+	assert.True(t, strings.HasSuffix(truncated, "…"))
+}
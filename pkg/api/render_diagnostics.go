@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/rendering"
+)
+
+// renderDiagnostics describes how a render went, for debugging renders. It
+// only has what Grafana observes of the render: the image renderer doesn't
+// report the browser's console warnings nor the data source queries of the
+// rendered page, so they aren't part of the diagnostics.
+type renderDiagnostics struct {
+	// URL is the Grafana URL the image renderer loaded.
+	URL               string                   `json:"url"`
+	Type              rendering.RenderType     `json:"type"`
+	Width             int                      `json:"width"`
+	Height            int                      `json:"height"`
+	DeviceScaleFactor float64                  `json:"deviceScaleFactor"`
+	Timeout           string                   `json:"timeout"`
+	Timings           renderDiagnosticsTimings `json:"timings"`
+	// ImageSize is the size in bytes of the image the render would have returned.
+	ImageSize int64 `json:"imageSize,omitempty"`
+	// Error is set if the render failed.
+	Error string `json:"error,omitempty"`
+}
+
+// renderDiagnosticsTimings are the durations of the phases of a render, in milliseconds.
+type renderDiagnosticsTimings struct {
+	// Queued is the time until the render was handed to the image renderer.
+	Queued int64 `json:"queued"`
+	// Rendering is the time the image renderer took.
+	Rendering int64 `json:"rendering"`
+	// PostProcessing is the time spent on the requested image post-processing.
+	PostProcessing int64 `json:"postProcessing"`
+	Total          int64 `json:"total"`
+}
+
+// renderDiagnostics renders and responds with the diagnostics of the render
+// instead of the rendered image. Failed renders are reported in the
+// diagnostics as well.
+func (hs *HTTPServer) renderDiagnostics(c *contextmodel.ReqContext, req renderRequest) {
+	diagnostics := renderDiagnostics{
+		Type:              req.Type,
+		Width:             req.Opts.Width,
+		Height:            req.Opts.Height,
+		DeviceScaleFactor: req.Opts.DeviceScaleFactor,
+		Timeout:           req.Opts.Timeout.String(),
+	}
+
+	start := time.Now()
+	renderingAt := start
+	opts := req.Opts
+	opts.OnProgress = func(phase rendering.RenderPhase) {
+		if phase == rendering.RenderPhaseRendering {
+			renderingAt = time.Now()
+		}
+	}
+
+	result, err := hs.RenderService.Render(c.Req.Context(), req.Type, opts, nil)
+	renderedAt := time.Now()
+	diagnostics.Timings.Queued = renderingAt.Sub(start).Milliseconds()
+	diagnostics.Timings.Rendering = renderedAt.Sub(renderingAt).Milliseconds()
+
+	if err == nil {
+		diagnostics.URL = result.URL
+		result.FilePath, err = processRenderImage(result.FilePath, req.Image)
+	}
+	if err == nil {
+		var info os.FileInfo
+		if info, err = os.Stat(result.FilePath); err == nil {
+			diagnostics.ImageSize = info.Size()
+		}
+	}
+	if err != nil {
+		diagnostics.Error = err.Error()
+	}
+
+	diagnostics.Timings.PostProcessing = time.Since(renderedAt).Milliseconds()
+	diagnostics.Timings.Total = time.Since(start).Milliseconds()
+
+	c.JSON(http.StatusOK, diagnostics)
+}
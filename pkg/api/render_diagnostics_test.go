@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/rendering"
+)
+
+func TestRenderHandlerDiagnostics(t *testing.T) {
+	t.Run("should return the diagnostics instead of the image", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		sc.user.IsGrafanaAdmin = true
+
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&width=800&height=400&diagnostics=true")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Header().Get("Content-Type"), "application/json")
+
+		var diagnostics renderDiagnostics
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &diagnostics))
+		assert.Equal(t, rendering.RenderPNG, diagnostics.Type)
+		assert.Equal(t, 800, diagnostics.Width)
+		assert.Equal(t, 400, diagnostics.Height)
+		assert.Equal(t, "1m0s", diagnostics.Timeout)
+		assert.Equal(t, int64(len("png")), diagnostics.ImageSize)
+		assert.Empty(t, diagnostics.Error)
+	})
+
+	t.Run("should report a failed render", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		sc.user.IsGrafanaAdmin = true
+		renderService := rendering.NewMockService(gomock.NewController(t))
+		renderService.EXPECT().Render(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ rendering.RenderType, _ rendering.Opts, _ rendering.Session) (*rendering.RenderResult, error) {
+				return nil, errors.New("renderer crashed")
+			})
+		sc.hs.RenderService = renderService
+
+		resp := sc.get(t, "/render/d/uid/slug?diagnostics=true")
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var diagnostics renderDiagnostics
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &diagnostics))
+		assert.Equal(t, "renderer crashed", diagnostics.Error)
+		assert.Zero(t, diagnostics.ImageSize)
+	})
+
+	t.Run("should reject diagnostics for non admins", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d/uid/slug?diagnostics=true")
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("should reject an invalid diagnostics", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d/uid/slug?diagnostics=maybe")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+)
+
+const (
+	// maxRenderFieldOverrides caps the number of field overrides of a render.
+	maxRenderFieldOverrides = 50
+	// maxRenderFieldOverridesSize caps the size of the field overrides once
+	// encoded as a query parameter of the rendered page's URL, which is itself
+	// passed on to the image renderer in the URL of the render request.
+	maxRenderFieldOverridesSize = 4 * 1024
+	// maxRenderBodySize caps the size of the body of a POST render request.
+	maxRenderBodySize = 16 * 1024
+	// maxRenderDecimals is the largest number of decimals a field can be shown with.
+	maxRenderDecimals = 20
+)
+
+// renderFieldMatchers are the accepted matchers of a render field override.
+var renderFieldMatchers = map[string]bool{"byName": true, "byRegexp": true, "byType": true, "byFrameRefID": true}
+
+// renderRequestBody is the body of a POST render request.
+type renderRequestBody struct {
+	// FieldOverrides are applied to the rendered panels for this render only,
+	// on top of the panels' own field config.
+	FieldOverrides json.RawMessage `json:"fieldOverrides"`
+}
+
+// renderFieldOverride has the format of an entry of a panel's
+// fieldConfig.overrides.
+type renderFieldOverride struct {
+	Matcher    renderFieldMatcher    `json:"matcher"`
+	Properties []renderFieldProperty `json:"properties"`
+}
+
+type renderFieldMatcher struct {
+	ID      string `json:"id"`
+	Options any    `json:"options,omitempty"`
+}
+
+type renderFieldProperty struct {
+	ID    string `json:"id"`
+	Value any    `json:"value"`
+}
+
+// readRenderRequestBody reads the body of a POST render request.
+func readRenderRequestBody(body io.Reader) (renderRequestBody, error) {
+	var result renderRequestBody
+	if body == nil {
+		return result, nil
+	}
+	decoder := json.NewDecoder(io.LimitReader(body, maxRenderBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil && !errors.Is(err, io.EOF) {
+		return result, err
+	}
+	return result, nil
+}
+
+// parseRenderFieldOverrides validates the field overrides of a render and
+// returns them as compact JSON, ready to be passed on to the renderer.
+func parseRenderFieldOverrides(value []byte) (string, error) {
+	var overrides []renderFieldOverride
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+	if err := decoder.Decode(&overrides); err != nil {
+		return "", errors.New("must be a JSON list of field overrides")
+	}
+	if decoder.More() {
+		return "", errors.New("must be a single JSON list of field overrides")
+	}
+	if len(overrides) == 0 || len(overrides) > maxRenderFieldOverrides {
+		return "", fmt.Errorf("must have between 1 and %d field overrides", maxRenderFieldOverrides)
+	}
+
+	for i, override := range overrides {
+		if !renderFieldMatchers[override.Matcher.ID] {
+			return "", fmt.Errorf("override %d must match fields byName, byRegexp, byType or byFrameRefID", i)
+		}
+		if len(override.Properties) == 0 {
+			return "", fmt.Errorf("override %d must have properties", i)
+		}
+		for _, property := range override.Properties {
+			if err := validateRenderFieldProperty(property); err != nil {
+				return "", fmt.Errorf("override %d: %w", i, err)
+			}
+		}
+	}
+
+	compact, err := json.Marshal(overrides)
+	if err != nil {
+		return "", err
+	}
+	if len(url.QueryEscape(string(compact))) > maxRenderFieldOverridesSize {
+		return "", fmt.Errorf("must be at most %d bytes once URL encoded", maxRenderFieldOverridesSize)
+	}
+	return string(compact), nil
+}
+
+func validateRenderFieldProperty(property renderFieldProperty) error {
+	switch property.ID {
+	case "":
+		return errors.New("property id must be set")
+	case "unit":
+		if _, ok := property.Value.(string); !ok {
+			return errors.New("unit must be a string")
+		}
+	case "decimals":
+		number, ok := property.Value.(json.Number)
+		if !ok {
+			return errors.New("decimals must be a number")
+		}
+		decimals, err := number.Float64()
+		if err != nil || decimals != math.Trunc(decimals) || decimals < 0 || decimals > maxRenderDecimals {
+			return fmt.Errorf("decimals must be an integer between 0 and %d", maxRenderDecimals)
+		}
+	}
+	return nil
+}
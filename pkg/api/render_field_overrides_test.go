@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRenderFieldOverrides(t *testing.T) {
+	t.Run("should compact valid overrides", func(t *testing.T) {
+		overrides, err := parseRenderFieldOverrides([]byte(`[
+			{"matcher": {"id": "byName", "options": "cpu"}, "properties": [{"id": "unit", "value": "percent"}, {"id": "decimals", "value": 2}]}
+		]`))
+		require.NoError(t, err)
+		assert.Equal(t, `[{"matcher":{"id":"byName","options":"cpu"},"properties":[{"id":"unit","value":"percent"},{"id":"decimals","value":2}]}]`, overrides)
+	})
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "malformed JSON", value: `[{"matcher":`},
+		{name: "not a list", value: `{"matcher": {"id": "byName"}}`},
+		{name: "empty list", value: `[]`},
+		{name: "unknown field", value: `[{"matcher": {"id": "byName"}, "properties": [{"id": "unit", "value": "ms"}], "extra": true}]`},
+		{name: "unknown matcher", value: `[{"matcher": {"id": "byMagic"}, "properties": [{"id": "unit", "value": "ms"}]}]`},
+		{name: "no properties", value: `[{"matcher": {"id": "byName", "options": "cpu"}}]`},
+		{name: "property without id", value: `[{"matcher": {"id": "byName"}, "properties": [{"value": "ms"}]}]`},
+		{name: "non-string unit", value: `[{"matcher": {"id": "byName"}, "properties": [{"id": "unit", "value": 1}]}]`},
+		{name: "fractional decimals", value: `[{"matcher": {"id": "byName"}, "properties": [{"id": "decimals", "value": 1.5}]}]`},
+		{name: "too many decimals", value: `[{"matcher": {"id": "byName"}, "properties": [{"id": "decimals", "value": 21}]}]`},
+		{name: "trailing data", value: `[{"matcher": {"id": "byName"}, "properties": [{"id": "unit", "value": "ms"}]}] []`},
+	}
+
+	for _, tt := range tests {
+		t.Run("should reject "+tt.name, func(t *testing.T) {
+			_, err := parseRenderFieldOverrides([]byte(tt.value))
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("should reject overrides that don't fit into the render URL", func(t *testing.T) {
+		// fewer overrides than the limit, but too long once URL encoded
+		override := `{"matcher": {"id": "byName", "options": "` + strings.Repeat("é", 200) + `"}, "properties": [{"id": "unit", "value": "ms"}]}`
+		_, err := parseRenderFieldOverrides([]byte("[" + strings.Repeat(override+",", 9) + override + "]"))
+		assert.ErrorContains(t, err, "URL encoded")
+	})
+}
+
+func TestRenderHandlerFieldOverrides(t *testing.T) {
+	const overrides = `[{"matcher":{"id":"byName","options":"cpu"},"properties":[{"id":"decimals","value":1}]}]`
+
+	t.Run("should pass the field overrides of the body to the render opts", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		req, err := http.NewRequest(http.MethodPost, "/render/d-solo/uid/slug?panelId=1", strings.NewReader(`{"fieldOverrides": `+overrides+`}`))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp := sc.send(t, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, overrides, sc.opts.FieldOverrides)
+	})
+
+	t.Run("should keep the panel config without field overrides", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		req, err := http.NewRequest(http.MethodPost, "/render/d-solo/uid/slug?panelId=1", nil)
+		require.NoError(t, err)
+
+		resp := sc.send(t, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, sc.opts.FieldOverrides)
+	})
+
+	t.Run("should reject malformed field overrides", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		req, err := http.NewRequest(http.MethodPost, "/render/d-solo/uid/slug?panelId=1", strings.NewReader(`{"fieldOverrides": [{"matcher": {"id": "byName"}}]}`))
+		require.NoError(t, err)
+
+		resp := sc.send(t, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should reject a malformed body", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		req, err := http.NewRequest(http.MethodPost, "/render/d-solo/uid/slug?panelId=1", strings.NewReader(`{"overrides": []}`))
+		require.NoError(t, err)
+
+		resp := sc.send(t, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should reject field overrides in both the query and the body", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		req, err := http.NewRequest(http.MethodPost, "/render/d-solo/uid/slug?panelId=1&fieldOverrides="+url.QueryEscape(overrides), strings.NewReader(`{"fieldOverrides": `+overrides+`}`))
+		require.NoError(t, err)
+
+		resp := sc.send(t, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
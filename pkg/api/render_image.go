@@ -0,0 +1,265 @@
+package api
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// renderFitContain letterboxes the rendered image into the requested dimensions.
+const renderFitContain = "contain"
+
+// renderImageOpts are the post-processing steps applied to a rendered PNG.
+type renderImageOpts struct {
+	// Fit, if set to renderFitContain, scales the image to fit into
+	// Width x Height while keeping its aspect ratio, and pads the rest of
+	// the canvas with Background.
+	Fit        string
+	Width      int
+	Height     int
+	Background color.Color
+	// Compression is the zlib compression level, from 0 to 9, the image is
+	// encoded with. If nil, the image is only re-encoded if other steps
+	// apply, with the default level.
+	Compression *int
+	// MaxWidth and MaxHeight, if set, downscale the image to fit into the
+	// bounding box while keeping its aspect ratio. Zero leaves the dimension
+	// unbounded.
+	MaxWidth  int
+	MaxHeight int
+	// Grayscale converts the image to shades of gray, keeping its transparency.
+	Grayscale bool
+}
+
+func (o renderImageOpts) isSet() bool {
+	return o.Fit != "" || o.Compression != nil || o.MaxWidth > 0 || o.MaxHeight > 0 || o.Grayscale
+}
+
+// compressionLevel maps the zlib compression level to the nearest level
+// supported by the PNG encoder.
+func (o renderImageOpts) compressionLevel() png.CompressionLevel {
+	switch {
+	case o.Compression == nil:
+		return png.DefaultCompression
+	case *o.Compression == 0:
+		return png.NoCompression
+	case *o.Compression <= 3:
+		return png.BestSpeed
+	case *o.Compression <= 6:
+		return png.DefaultCompression
+	default:
+		return png.BestCompression
+	}
+}
+
+// processRenderImage applies the post-processing steps to the PNG at
+// filePath and returns the path of the processed image, which is written
+// next to the original.
+func processRenderImage(filePath string, opts renderImageOpts) (string, error) {
+	if !opts.isSet() {
+		return filePath, nil
+	}
+
+	img, err := readPNG(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.Fit == renderFitContain {
+		img = letterboxImage(img, opts.Width, opts.Height, opts.Background)
+	}
+
+	if opts.MaxWidth > 0 || opts.MaxHeight > 0 {
+		img = downscaleImage(img, opts.MaxWidth, opts.MaxHeight)
+	}
+
+	if opts.Grayscale {
+		img = grayscaleImage(img)
+	}
+
+	return writePNG(filepath.Dir(filePath), img, opts.compressionLevel())
+}
+
+func readPNG(filePath string) (image.Image, error) {
+	//nolint:gosec
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode rendered image: %w", err)
+	}
+	return img, nil
+}
+
+// readPNGSize returns the dimensions of the PNG at filePath.
+func readPNGSize(filePath string) (image.Point, error) {
+	//nolint:gosec
+	f, err := os.Open(filePath)
+	if err != nil {
+		return image.Point{}, err
+	}
+	defer func() { _ = f.Close() }()
+
+	config, err := png.DecodeConfig(f)
+	if err != nil {
+		return image.Point{}, fmt.Errorf("failed to decode rendered image: %w", err)
+	}
+	return image.Pt(config.Width, config.Height), nil
+}
+
+func writePNG(dir string, img image.Image, level png.CompressionLevel) (string, error) {
+	f, err := os.CreateTemp(dir, "*.png")
+	if err != nil {
+		return "", err
+	}
+
+	encoder := png.Encoder{CompressionLevel: level}
+	if err := encoder.Encode(f, img); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// letterboxImage scales img to fit into a width x height canvas while keeping
+// its aspect ratio, centered and padded with background.
+func letterboxImage(img image.Image, width, height int, background color.Color) image.Image {
+	bounds := img.Bounds()
+	scaledWidth, scaledHeight := width, height
+	if bounds.Dx()*height > bounds.Dy()*width {
+		scaledHeight = max(1, bounds.Dy()*width/bounds.Dx())
+	} else {
+		scaledWidth = max(1, bounds.Dx()*height/bounds.Dy())
+	}
+
+	canvas := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	offset := image.Pt((width-scaledWidth)/2, (height-scaledHeight)/2)
+	scaled := scaleImage(img, scaledWidth, scaledHeight)
+	draw.Draw(canvas, scaled.Bounds().Add(offset), scaled, image.Point{}, draw.Over)
+
+	return canvas
+}
+
+// downscaleImage scales img down to fit into a maxWidth x maxHeight bounding
+// box while keeping its aspect ratio. A zero bound leaves the dimension
+// unbounded, and images that already fit are returned as is.
+func downscaleImage(img image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if maxWidth > 0 && width > maxWidth {
+		width, height = maxWidth, max(1, height*maxWidth/width)
+	}
+	if maxHeight > 0 && height > maxHeight {
+		width, height = max(1, width*maxHeight/height), maxHeight
+	}
+	if width == bounds.Dx() && height == bounds.Dy() {
+		return img
+	}
+	return scaleImage(img, width, height)
+}
+
+// grayscaleImage converts img to shades of gray using the luma of its
+// colors, keeping the alpha channel.
+func grayscaleImage(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	gray := image.NewNRGBA(bounds)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			// the same coefficients as color.GrayModel
+			luma := uint8((19595*uint32(c.R) + 38470*uint32(c.G) + 7471*uint32(c.B) + 1<<15) >> 16)
+			gray.SetNRGBA(x, y, color.NRGBA{R: luma, G: luma, B: luma, A: c.A})
+		}
+	}
+
+	return gray
+}
+
+// scaleImage resizes img to width x height, averaging the source pixels
+// covered by each target pixel.
+func scaleImage(img image.Image, width, height int) *image.NRGBA {
+	bounds := img.Bounds()
+	scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*bounds.Dx()/width)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			scaled.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+
+	return scaled
+}
+
+// renderNamedColors are the color names accepted in render parameters.
+var renderNamedColors = map[string]color.NRGBA{
+	"transparent": {},
+	"black":       {A: 255},
+	"white":       {R: 255, G: 255, B: 255, A: 255},
+	"gray":        {R: 128, G: 128, B: 128, A: 255},
+	"grey":        {R: 128, G: 128, B: 128, A: 255},
+	"silver":      {R: 192, G: 192, B: 192, A: 255},
+	"red":         {R: 255, A: 255},
+	"green":       {G: 128, A: 255},
+	"blue":        {B: 255, A: 255},
+	"yellow":      {R: 255, G: 255, A: 255},
+	"orange":      {R: 255, G: 165, A: 255},
+	"purple":      {R: 128, B: 128, A: 255},
+}
+
+// parseRenderColor parses a color given as #rgb, #rrggbb, #rrggbbaa or one
+// of the renderNamedColors.
+func parseRenderColor(value string) (color.NRGBA, error) {
+	if c, ok := renderNamedColors[strings.ToLower(value)]; ok {
+		return c, nil
+	}
+
+	hex, ok := strings.CutPrefix(value, "#")
+	if !ok {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q", value)
+	}
+	if len(hex) == 3 {
+		hex = strings.Repeat(hex[0:1], 2) + strings.Repeat(hex[1:2], 2) + strings.Repeat(hex[2:3], 2)
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q", value)
+	}
+
+	rgba, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q", value)
+	}
+	return color.NRGBA{R: uint8(rgba >> 24), G: uint8(rgba >> 16), B: uint8(rgba >> 8), A: uint8(rgba)}, nil
+}
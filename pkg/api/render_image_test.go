@@ -0,0 +1,112 @@
+package api
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLetterboxImage(t *testing.T) {
+	blue := color.NRGBA{B: 255, A: 255}
+
+	t.Run("should pad a wide image at the top and bottom", func(t *testing.T) {
+		img := image.NewUniform(blue)
+		result := letterboxImage(&boundedImage{img, image.Rect(0, 0, 400, 100)}, 200, 200, color.Black)
+		assert.Equal(t, image.Rect(0, 0, 200, 200), result.Bounds())
+		assert.Equal(t, color.NRGBAModel.Convert(color.Black), result.At(100, 10))
+		assert.Equal(t, blue, result.At(100, 100))
+	})
+
+	t.Run("should pad a tall image at the sides", func(t *testing.T) {
+		img := image.NewUniform(blue)
+		result := letterboxImage(&boundedImage{img, image.Rect(0, 0, 100, 400)}, 200, 200, color.Black)
+		assert.Equal(t, image.Rect(0, 0, 200, 200), result.Bounds())
+		assert.Equal(t, color.NRGBAModel.Convert(color.Black), result.At(10, 100))
+		assert.Equal(t, blue, result.At(100, 100))
+	})
+}
+
+func TestDownscaleImage(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 400, 100))
+
+	assert.Equal(t, image.Rect(0, 0, 200, 50), downscaleImage(img, 200, 0).Bounds())
+	assert.Equal(t, image.Rect(0, 0, 200, 50), downscaleImage(img, 0, 50).Bounds())
+	assert.Equal(t, image.Rect(0, 0, 120, 30), downscaleImage(img, 200, 30).Bounds())
+	assert.Same(t, img, downscaleImage(img, 800, 200))
+}
+
+func TestGrayscaleImage(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	img.Set(1, 0, color.NRGBA{G: 255, B: 255, A: 128})
+
+	result := grayscaleImage(img)
+	assert.Equal(t, color.NRGBA{R: 76, G: 76, B: 76, A: 255}, result.At(0, 0))
+	assert.Equal(t, color.NRGBA{R: 179, G: 179, B: 179, A: 128}, result.At(1, 0))
+}
+
+func TestProcessRenderImageCompression(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 200, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 200; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: uint8(x / 10 * 10), A: 255})
+		}
+	}
+	filePath := filepath.Join(t.TempDir(), "render.png")
+	f, err := os.Create(filePath)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, img))
+	require.NoError(t, f.Close())
+
+	fileSize := func(compression int) int64 {
+		processed, err := processRenderImage(filePath, renderImageOpts{Compression: &compression})
+		require.NoError(t, err)
+		info, err := os.Stat(processed)
+		require.NoError(t, err)
+		return info.Size()
+	}
+
+	none, fast, best := fileSize(0), fileSize(1), fileSize(9)
+	assert.LessOrEqual(t, fast, none)
+	assert.LessOrEqual(t, best, fast)
+	assert.Less(t, best, none)
+}
+
+func TestParseRenderColor(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected color.NRGBA
+	}{
+		{value: "#fff", expected: color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+		{value: "#102030", expected: color.NRGBA{R: 16, G: 32, B: 48, A: 255}},
+		{value: "#10203040", expected: color.NRGBA{R: 16, G: 32, B: 48, A: 64}},
+		{value: "transparent", expected: color.NRGBA{}},
+		{value: "White", expected: color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+	}
+	for _, tc := range tests {
+		c, err := parseRenderColor(tc.value)
+		require.NoError(t, err, tc.value)
+		assert.Equal(t, tc.expected, c, tc.value)
+	}
+
+	for _, value := range []string{"", "fff", "#ff", "#gggggg", "#1020304050"} {
+		_, err := parseRenderColor(value)
+		assert.Error(t, err, value)
+	}
+}
+
+// boundedImage limits an image to the given bounds.
+type boundedImage struct {
+	image.Image
+	bounds image.Rectangle
+}
+
+func (i *boundedImage) Bounds() image.Rectangle {
+	return i.bounds
+}
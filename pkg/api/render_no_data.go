@@ -0,0 +1,110 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// renderHasNoData tells whether none of the rendered panels have data in the
+// time range of the render. The panels are queried the way the frontend
+// queries them, with the render's time range and resolution. Panels whose
+// queries use template variables count as having data, as only the frontend
+// interpolates the variables.
+//
+// The queries are made in addition to the ones of the rendered page, so the
+// check stops at the first panel with data: renders with data pay for the
+// queries of the panels up to that one. If the dashboard can't be read, an
+// error response is written and ok is false.
+func (hs *HTTPServer) renderHasNoData(c *contextmodel.ReqContext, req renderRequest) (noData bool, ok bool) {
+	dashboard, errResp := hs.getRenderDashboard(c, getRenderDashboardUID(web.Params(c.Req)["*"]))
+	if errResp != nil {
+		errResp.WriteTo(c)
+		return false, false
+	}
+
+	panelIDs := []int64{req.PanelID}
+	if req.PanelID == 0 {
+		panelIDs = getVisibleRenderPanelIDs(dashboard.Data)
+	}
+
+	for _, panelID := range panelIDs {
+		resp, err := hs.getRenderPanelData(c, req, dashboard, panelID)
+		var variable errRenderPanelVariable
+		if errors.As(err, &variable) {
+			return false, true
+		}
+		if err != nil {
+			// the render shows the failure in its own way
+			hs.log.Warn("Failed to check whether the render has data", "panelId", panelID, "error", err)
+			return false, true
+		}
+		if renderPanelHasData(resp) {
+			return false, true
+		}
+	}
+
+	return true, true
+}
+
+// getVisibleRenderPanelIDs returns the IDs of the panels shown in a dashboard
+// render. The panels of collapsed rows aren't shown, and the panels of
+// expanded rows follow their row in the dashboard's panels.
+func getVisibleRenderPanelIDs(data *simplejson.Json) []int64 {
+	var panelIDs []int64
+	for _, panelObj := range data.Get("panels").MustArray() {
+		panel := simplejson.NewFromAny(panelObj)
+		if panel.Get("type").MustString() == "row" {
+			continue
+		}
+		panelIDs = append(panelIDs, panel.Get("id").MustInt64())
+	}
+	return panelIDs
+}
+
+// renderPanelHasData tells whether the query results of a panel have data.
+// Panels without queries, such as text panels, and failed queries count as
+// having data: they show something other than "no data" when rendered.
+func renderPanelHasData(resp *backend.QueryDataResponse) bool {
+	if len(resp.Responses) == 0 {
+		return true
+	}
+
+	for _, dataResponse := range resp.Responses {
+		if dataResponse.Error != nil {
+			return true
+		}
+		for _, frame := range dataResponse.Frames {
+			if frame.Rows() > 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// renderNoDataPlaceholder responds with the "no data" placeholder image, with
+// the X-Render-No-Data header to tell it apart from a rendered image.
+func (hs *HTTPServer) renderNoDataPlaceholder(c *contextmodel.ReqContext, req renderRequest) {
+	result, err := hs.RenderService.RenderNoDataImage(req.Opts.Theme)
+	if err != nil {
+		hs.handleRenderError(c, req, "Rendering failed.", err)
+		return
+	}
+
+	c.Resp.Header().Set("X-Render-No-Data", "true")
+	if req.Format == renderFormatJSON {
+		hs.renderJSON(c, req, result)
+		return
+	}
+
+	c.Resp.Header().Set("Content-Type", renderContentType(req.Type))
+	c.Resp.Header().Set("Cache-Control", "private")
+	http.ServeFile(c.Resp, c.Req, result.FilePath)
+}
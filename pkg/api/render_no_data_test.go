@@ -0,0 +1,161 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/rendering"
+)
+
+func TestRenderHandlerNoDataPlaceholder(t *testing.T) {
+	dashboardJSON := simplejson.NewFromAny(map[string]any{
+		"panels": []any{
+			map[string]any{"id": 1, "type": "timeseries", "targets": []any{map[string]any{"refId": "A", "expr": "up"}}},
+			map[string]any{"id": 2, "type": "stat", "targets": []any{map[string]any{"refId": "B"}}},
+			map[string]any{"id": 3, "type": "row", "collapsed": true, "panels": []any{
+				map[string]any{"id": 4, "type": "timeseries", "targets": []any{map[string]any{"refId": "C"}}},
+			}},
+		},
+	})
+	emptyResponse := &backend.QueryDataResponse{Responses: backend.Responses{
+		"A": backend.DataResponse{Frames: data.Frames{data.NewFrame("empty")}},
+	}}
+	dataResponse := &backend.QueryDataResponse{Responses: backend.Responses{
+		"A": backend.DataResponse{Frames: data.Frames{data.NewFrame("up", data.NewField("value", nil, []float64{1}))}},
+	}}
+
+	setup := func(t *testing.T) (*renderScenarioContext, *query.FakeQueryService) {
+		sc := setupRenderScenario(t)
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardService.On("GetDashboard", mock.Anything, &dashboards.GetDashboardQuery{UID: "uid", OrgID: testOrgID}).
+			Return(&dashboards.Dashboard{ID: 5, UID: "uid", OrgID: testOrgID, Data: dashboardJSON}, nil).Maybe()
+		queryService := &query.FakeQueryService{}
+		sc.hs.DashboardService = dashboardService
+		sc.hs.queryDataService = queryService
+		guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanViewValue: true})
+		return sc, queryService
+	}
+
+	t.Run("should return the placeholder if no panel has data", func(t *testing.T) {
+		sc, queryService := setup(t)
+		queryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(emptyResponse, nil)
+		renderService := rendering.NewMockService(gomock.NewController(t))
+		renderService.EXPECT().RenderNoDataImage(models.ThemeLight).Return(&rendering.RenderResult{FilePath: sc.filePath, NoData: true}, nil)
+		sc.hs.RenderService = renderService
+
+		resp := sc.get(t, "/render/d/uid/slug?theme=light&noDataPlaceholder=true")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "true", resp.Header().Get("X-Render-No-Data"))
+		assert.Equal(t, "image/png", resp.Header().Get("Content-Type"))
+		// the panel of the collapsed row isn't shown, so it isn't checked
+		queryService.AssertNumberOfCalls(t, "QueryData", 2)
+	})
+
+	t.Run("should render if a panel has data", func(t *testing.T) {
+		sc, queryService := setup(t)
+		queryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(dataResponse, nil)
+
+		resp := sc.get(t, "/render/d/uid/slug?noDataPlaceholder=true")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, resp.Header().Get("X-Render-No-Data"))
+		assert.Equal(t, "d/uid/slug?noDataPlaceholder=true", sc.opts.Path)
+	})
+
+	t.Run("should render if the data can't be checked", func(t *testing.T) {
+		sc, queryService := setup(t)
+		queryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, assert.AnError)
+
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&noDataPlaceholder=true")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, resp.Header().Get("X-Render-No-Data"))
+		assert.NotEmpty(t, sc.opts.Path)
+	})
+
+	t.Run("should render if a panel uses template variables", func(t *testing.T) {
+		sc, queryService := setup(t)
+		queryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(emptyResponse, nil)
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(&dashboards.Dashboard{ID: 5, UID: "uid", OrgID: testOrgID, Data: simplejson.NewFromAny(map[string]any{
+			"panels": []any{
+				map[string]any{"id": 1, "type": "timeseries", "targets": []any{map[string]any{"refId": "A", "expr": `up{env="$env"}`}}},
+			},
+			"templating": map[string]any{"list": []any{
+				map[string]any{"name": "env", "type": "query", "current": map[string]any{"value": "prod"}},
+			}},
+		})}, nil)
+		sc.hs.DashboardService = dashboardService
+
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=panel-1&var-env=staging&noDataPlaceholder=true")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, resp.Header().Get("X-Render-No-Data"))
+		assert.NotEmpty(t, sc.opts.Path)
+		queryService.AssertNotCalled(t, "QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should require a panelId for single-panel renders", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d-solo/uid/slug?noDataPlaceholder=true")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should reject the placeholder for PDF renders", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d/uid/slug?encoding=pdf&noDataPlaceholder=true")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestRenderPanelHasData(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     *backend.QueryDataResponse
+		expected bool
+	}{
+		{
+			name:     "panel without queries",
+			resp:     &backend.QueryDataResponse{Responses: backend.Responses{}},
+			expected: true,
+		},
+		{
+			name: "frames without rows",
+			resp: &backend.QueryDataResponse{Responses: backend.Responses{
+				"A": backend.DataResponse{Frames: data.Frames{data.NewFrame("empty", data.NewField("value", nil, []float64{}))}},
+				"B": backend.DataResponse{},
+			}},
+			expected: false,
+		},
+		{
+			name: "frame with rows",
+			resp: &backend.QueryDataResponse{Responses: backend.Responses{
+				"A": backend.DataResponse{},
+				"B": backend.DataResponse{Frames: data.Frames{data.NewFrame("up", data.NewField("value", nil, []float64{1}))}},
+			}},
+			expected: true,
+		},
+		{
+			name: "failed query",
+			resp: &backend.QueryDataResponse{Responses: backend.Responses{
+				"A": backend.DataResponse{Error: assert.AnError},
+			}},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, renderPanelHasData(tt.resp))
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const (
+	// maxRenderPanels bounds the number of panels rendered by a single request.
+	maxRenderPanels = 100
+	// renderPanelsConcurrency is the number of panels of a dashboard rendered at the same time.
+	renderPanelsConcurrency = 4
+)
+
+// renderPanelImage is the solo render of a dashboard panel. Image is the
+// base64 encoded PNG, or empty if the panel couldn't be rendered.
+type renderPanelImage struct {
+	PanelID int64  `json:"panelId"`
+	Title   string `json:"title"`
+	Image   string `json:"image,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RenderDashboardPanels renders each panel of a dashboard as its own image.
+// Panels that fail to render are reported with an error instead of an image.
+func (hs *HTTPServer) RenderDashboardPanels(c *contextmodel.ReqContext) response.Response {
+	dashboard, errResp := hs.getRenderDashboard(c, web.Params(c.Req)[":uid"])
+	if errResp != nil {
+		return errResp
+	}
+
+	panels := getRenderablePanels(dashboard.Data)
+	if len(panels) > maxRenderPanels {
+		return response.Error(http.StatusBadRequest, fmt.Sprintf("Render parameters error: dashboards with more than %d panels can't be rendered per panel", maxRenderPanels), nil)
+	}
+
+	width := c.QueryInt("width")
+	if width == 0 {
+		width = hs.Cfg.RendererDefaultImageWidth
+	}
+	height := c.QueryInt("height")
+	if height == 0 {
+		height = hs.Cfg.RendererDefaultImageHeight
+	}
+	if width < 0 || height < 0 {
+		return response.Error(http.StatusBadRequest, "Render parameters error: width and height must be positive", nil)
+	}
+
+	userID, err := identity.UserIdentifier(c.SignedInUser.GetNamespacedID())
+	if err != nil {
+		hs.log.Error("Failed to parse user id", "err", err)
+	}
+	opts := rendering.Opts{
+		CommonOpts: rendering.CommonOpts{
+			TimeoutOpts: rendering.TimeoutOpts{Timeout: 60 * time.Second},
+			AuthOpts: rendering.AuthOpts{
+				OrgID:   c.SignedInUser.GetOrgID(),
+				UserID:  userID,
+				OrgRole: c.SignedInUser.GetOrgRole(),
+			},
+			ConcurrentLimit: hs.Cfg.RendererConcurrentRequestLimit,
+		},
+		ErrorOpts: rendering.ErrorOpts{
+			ErrorConcurrentLimitReached: true,
+			ErrorRenderUnavailable:      true,
+		},
+		Width:             width,
+		Height:            height,
+		DeviceScaleFactor: 1,
+		DisableAnimations: true,
+	}
+
+	var g errgroup.Group
+	g.SetLimit(renderPanelsConcurrency)
+	for _, panel := range panels {
+		g.Go(func() error {
+			content, err := hs.renderPanelImage(c.Req.Context(), dashboard, panel.PanelID, opts)
+			if err != nil {
+				hs.log.Warn("Failed to render panel", "dashboardUID", dashboard.UID, "panelId", panel.PanelID, "error", err)
+				panel.Error = "Rendering failed"
+				return nil
+			}
+			panel.Image = base64.StdEncoding.EncodeToString(content)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	return response.JSON(http.StatusOK, panels)
+}
+
+func (hs *HTTPServer) renderPanelImage(ctx context.Context, dashboard *dashboards.Dashboard, panelID int64, opts rendering.Opts) ([]byte, error) {
+	u := url.URL{Path: path.Join("d-solo", dashboard.UID, dashboard.Slug)}
+	q := u.Query()
+	q.Set("orgId", strconv.FormatInt(dashboard.OrgID, 10))
+	q.Set("panelId", strconv.FormatInt(panelID, 10))
+	u.RawQuery = q.Encode()
+	opts.Path = u.String()
+
+	result, err := hs.RenderService.Render(ctx, rendering.RenderPNG, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(result.FilePath)
+}
+
+// getRenderablePanels returns the panels of a dashboard, including those in
+// collapsed rows, but not the rows themselves.
+func getRenderablePanels(data *simplejson.Json) []*renderPanelImage {
+	panels := []*renderPanelImage{}
+	for _, panelObj := range data.Get("panels").MustArray() {
+		panel := simplejson.NewFromAny(panelObj)
+		if panel.Get("type").MustString() == "row" {
+			panels = append(panels, getRenderablePanels(panel)...)
+			continue
+		}
+		panels = append(panels, &renderPanelImage{
+			PanelID: panel.Get("id").MustInt64(),
+			Title:   panel.Get("title").MustString(),
+		})
+	}
+	return panels
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/rendering"
+)
+
+func TestRenderDashboardPanels(t *testing.T) {
+	setup := func(t *testing.T, data *simplejson.Json) *renderScenarioContext {
+		sc := setupRenderScenario(t)
+		renderService := rendering.NewMockService(gomock.NewController(t))
+		renderService.EXPECT().Render(gomock.Any(), rendering.RenderPNG, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ rendering.RenderType, opts rendering.Opts, _ rendering.Session) (*rendering.RenderResult, error) {
+				if strings.Contains(opts.Path, "panelId=3") {
+					return nil, errors.New("render failed")
+				}
+				return &rendering.RenderResult{FilePath: sc.filePath}, nil
+			}).AnyTimes()
+		sc.hs.RenderService = renderService
+
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardService.On("GetDashboard", mock.Anything, &dashboards.GetDashboardQuery{UID: "uid", OrgID: testOrgID}).
+			Return(&dashboards.Dashboard{UID: "uid", Slug: "slug", OrgID: testOrgID, Data: data}, nil).Maybe()
+		dashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(nil, dashboards.ErrDashboardNotFound).Maybe()
+		sc.hs.DashboardService = dashboardService
+		guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanViewValue: true})
+		return sc
+	}
+
+	get := func(t *testing.T, sc *renderScenarioContext, url string) *httptest.ResponseRecorder {
+		scenario := setupScenarioContext(t, url)
+		scenario.m.Get("/api/render/dashboards/:uid/panels", routing.Wrap(func(c *contextmodel.ReqContext) response.Response {
+			c.SignedInUser = sc.user
+			return sc.hs.RenderDashboardPanels(c)
+		}))
+		scenario.req = httptest.NewRequest(http.MethodGet, url, nil)
+		scenario.resp = httptest.NewRecorder()
+		scenario.exec()
+		return scenario.resp
+	}
+
+	t.Run("should render each panel of the dashboard", func(t *testing.T) {
+		sc := setup(t, simplejson.NewFromAny(map[string]any{
+			"panels": []any{
+				map[string]any{"id": 1, "title": "CPU", "type": "timeseries"},
+				map[string]any{"id": 2, "title": "Row", "type": "row", "collapsed": true, "panels": []any{
+					map[string]any{"id": 3, "title": "Memory", "type": "timeseries"},
+				}},
+				map[string]any{"id": 4, "title": "Disk", "type": "stat"},
+			},
+		}))
+
+		resp := get(t, sc, "/api/render/dashboards/uid/panels")
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var result []renderPanelImage
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+		require.Len(t, result, 3)
+
+		image := base64.StdEncoding.EncodeToString([]byte("png"))
+		assert.Equal(t, renderPanelImage{PanelID: 1, Title: "CPU", Image: image}, result[0])
+		assert.Equal(t, renderPanelImage{PanelID: 3, Title: "Memory", Error: "Rendering failed"}, result[1])
+		assert.Equal(t, renderPanelImage{PanelID: 4, Title: "Disk", Image: image}, result[2])
+	})
+
+	t.Run("should reject dashboards with too many panels", func(t *testing.T) {
+		panels := make([]any, maxRenderPanels+1)
+		for i := range panels {
+			panels[i] = map[string]any{"id": i + 1, "type": "stat"}
+		}
+		sc := setup(t, simplejson.NewFromAny(map[string]any{"panels": panels}))
+
+		resp := get(t, sc, "/api/render/dashboards/uid/panels")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should return not found for an unknown dashboard", func(t *testing.T) {
+		sc := setup(t, simplejson.New())
+
+		resp := get(t, sc, "/api/render/dashboards/unknown/panels")
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
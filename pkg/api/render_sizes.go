@@ -0,0 +1,107 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"strconv"
+	"strings"
+
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/rendering"
+)
+
+const (
+	// maxRenderSizes caps the number of sizes a single render can be returned in.
+	maxRenderSizes = 5
+	// maxRenderSizeDimension caps the width and height of a size, as the
+	// image renderer doesn't render a larger viewport by default.
+	maxRenderSizeDimension = 3000
+)
+
+// renderSizesResult is the response of a render with multiple sizes.
+type renderSizesResult struct {
+	ContentType string            `json:"contentType"`
+	Images      []renderSizeImage `json:"images"`
+}
+
+type renderSizeImage struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// Image is the base64 encoded image.
+	Image string `json:"image"`
+}
+
+// parseRenderSizes parses a comma separated list of sizes, such as
+// 320x180,1600x900. The sizes are all made from a single render, so they
+// must have the same aspect ratio, give or take a pixel of rounding.
+func parseRenderSizes(value string) ([]image.Point, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) > maxRenderSizes {
+		return nil, fmt.Errorf("can have at most %d sizes", maxRenderSizes)
+	}
+
+	sizes := make([]image.Point, 0, len(parts))
+	for _, part := range parts {
+		w, h, ok := strings.Cut(strings.TrimSpace(part), "x")
+		width, errWidth := strconv.Atoi(w)
+		height, errHeight := strconv.Atoi(h)
+		if !ok || errWidth != nil || errHeight != nil || width <= 0 || height <= 0 {
+			return nil, fmt.Errorf("must be a list of sizes such as 320x180,1600x900, got %q", part)
+		}
+		if width > maxRenderSizeDimension || height > maxRenderSizeDimension {
+			return nil, fmt.Errorf("can't be larger than %dx%d, got %q", maxRenderSizeDimension, maxRenderSizeDimension, part)
+		}
+		sizes = append(sizes, image.Pt(width, height))
+	}
+
+	largest := sizes[0]
+	for _, size := range sizes {
+		if size.X > largest.X {
+			largest = size
+		}
+	}
+	for _, size := range sizes {
+		// the width of the size at the aspect ratio of the largest size must be within a pixel of its own
+		if diff := size.X*largest.Y - size.Y*largest.X; diff > largest.Y || -diff > largest.Y {
+			return nil, fmt.Errorf("must all have the same aspect ratio, got %dx%d and %dx%d", largest.X, largest.Y, size.X, size.Y)
+		}
+	}
+	return sizes, nil
+}
+
+// renderSizes responds with the rendered image downscaled to each of the
+// requested sizes, keeping its aspect ratio.
+func (hs *HTTPServer) renderSizes(c *contextmodel.ReqContext, req renderRequest, result *rendering.RenderResult) {
+	img, err := readPNG(result.FilePath)
+	if err != nil {
+		hs.handleRenderError(c, req, "Rendering failed.", err)
+		return
+	}
+
+	encoder := png.Encoder{CompressionLevel: req.Image.compressionLevel()}
+	images := make([]renderSizeImage, 0, len(req.Sizes))
+	for _, size := range req.Sizes {
+		scaled := downscaleImage(img, size.X, size.Y)
+
+		var buf bytes.Buffer
+		if err := encoder.Encode(&buf, scaled); err != nil {
+			hs.handleRenderError(c, req, "Rendering failed.", err)
+			return
+		}
+		images = append(images, renderSizeImage{
+			Width:  scaled.Bounds().Dx(),
+			Height: scaled.Bounds().Dy(),
+			Image:  base64.StdEncoding.EncodeToString(buf.Bytes()),
+		})
+	}
+
+	c.Resp.Header().Set("Cache-Control", "private")
+	c.JSON(http.StatusOK, renderSizesResult{
+		ContentType: renderContentType(req.Type),
+		Images:      images,
+	})
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/rendering"
+)
+
+func TestParseRenderSizes(t *testing.T) {
+	sizes, err := parseRenderSizes("320x180, 1600x900")
+	require.NoError(t, err)
+	assert.Equal(t, []image.Point{image.Pt(320, 180), image.Pt(1600, 900)}, sizes)
+
+	// sizes rounded to whole pixels have the same aspect ratio
+	_, err = parseRenderSizes("100x56,1600x900")
+	require.NoError(t, err)
+
+	for _, value := range []string{"320", "320x", "x180", "0x180", "320x-1", "wide x tall", "1x1,2x2,3x3,4x4,5x5,6x6", "3200x1800", "1000x3001"} {
+		_, err := parseRenderSizes(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestRenderHandlerSizes(t *testing.T) {
+	t.Run("should produce all sizes from a single render", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		sc.setRenderImage(t, image.NewNRGBA(image.Rect(0, 0, 1600, 900)))
+		var opts rendering.Opts
+		renderService := rendering.NewMockService(gomock.NewController(t))
+		renderService.EXPECT().Render(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ rendering.RenderType, o rendering.Opts, _ rendering.Session) (*rendering.RenderResult, error) {
+				opts = o
+				return &rendering.RenderResult{FilePath: sc.filePath}, nil
+			}).Times(1)
+		sc.hs.RenderService = renderService
+
+		resp := sc.get(t, "/render/d/uid/slug?sizes=320x180,800x450,1600x900")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, 1600, opts.Width)
+		assert.Equal(t, 900, opts.Height)
+
+		var body renderSizesResult
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		assert.Equal(t, "image/png", body.ContentType)
+		require.Len(t, body.Images, 3)
+		for i, expected := range []image.Point{image.Pt(320, 180), image.Pt(800, 450), image.Pt(1600, 900)} {
+			assert.Equal(t, expected, image.Pt(body.Images[i].Width, body.Images[i].Height))
+
+			content, err := base64.StdEncoding.DecodeString(body.Images[i].Image)
+			require.NoError(t, err)
+			img, err := png.Decode(bytes.NewReader(content))
+			require.NoError(t, err)
+			assert.Equal(t, image.Rectangle{Max: expected}, img.Bounds())
+		}
+	})
+
+	t.Run("should reject sizes combined with explicit dimensions", func(t *testing.T) {
+		for _, query := range []string{"width=800", "maxHeight=100", "fit=contain", "format=json", "encoding=pdf"} {
+			sc := setupRenderScenario(t)
+			resp := sc.get(t, "/render/d/uid/slug?sizes=320x180&"+query)
+			assert.Equal(t, http.StatusBadRequest, resp.Code, query)
+		}
+	})
+
+	t.Run("should reject sizes with mixed aspect ratios", func(t *testing.T) {
+		for _, value := range []string{"1000x100,100x1000", "320x180,800x800"} {
+			sc := setupRenderScenario(t)
+			resp := sc.get(t, "/render/d/uid/slug?sizes="+value)
+			assert.Equal(t, http.StatusBadRequest, resp.Code, value)
+			assert.Contains(t, resp.Body.String(), "aspect ratio", value)
+		}
+	})
+
+	t.Run("should reject invalid sizes", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d/uid/slug?sizes=big")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// renderTemplatePlaceholder matches the named placeholders of a render URL
+// template, such as {uid}.
+var renderTemplatePlaceholder = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+// RenderTemplate renders the path built from a configured render URL
+// template, with its placeholders replaced by the query parameters of the
+// request. The built path is rendered as if it was requested from /render.
+func (hs *HTTPServer) RenderTemplate(c *contextmodel.ReqContext) {
+	template, ok := hs.Cfg.RendererURLTemplates[web.Params(c.Req)[":name"]]
+	if !ok {
+		c.JsonApiErr(http.StatusNotFound, "Render template not found", nil)
+		return
+	}
+
+	path, missing := expandRenderTemplate(template, c.Req.URL.Query())
+	if len(missing) > 0 {
+		c.JsonApiErr(http.StatusBadRequest, fmt.Sprintf("Render parameters error: missing template parameters: %s", strings.Join(missing, ", ")), nil)
+		return
+	}
+
+	renderURL, err := url.Parse(path)
+	if err != nil {
+		c.JsonApiErr(http.StatusInternalServerError, "Invalid render template", err)
+		return
+	}
+
+	// the render reads the path from the route parameters and the render
+	// parameters from the query. The escaped path keeps substituted values
+	// from adding path segments.
+	reqURL := *c.Req.URL
+	reqURL.RawQuery = renderURL.RawQuery
+	c.Req = web.SetURLParams(c.Req, map[string]string{"*": strings.TrimPrefix(renderURL.EscapedPath(), "/")})
+	c.Req.URL = &reqURL
+
+	hs.RenderHandler(c)
+}
+
+// expandRenderTemplate replaces the placeholders of a render URL template
+// with the values of params, escaped for the part of the URL they are in. If
+// any placeholder has no value, the sorted names of the missing placeholders
+// are returned instead.
+func expandRenderTemplate(template string, params url.Values) (string, []string) {
+	missingSet := map[string]bool{}
+	expand := func(part string, escape func(string) string) string {
+		return renderTemplatePlaceholder.ReplaceAllStringFunc(part, func(placeholder string) string {
+			name := placeholder[1 : len(placeholder)-1]
+			value := params.Get(name)
+			if value == "" {
+				missingSet[name] = true
+				return placeholder
+			}
+			return escape(value)
+		})
+	}
+
+	path, query, hasQuery := strings.Cut(template, "?")
+	expanded := expand(path, url.PathEscape)
+	if hasQuery {
+		expanded += "?" + expand(query, url.QueryEscape)
+	}
+
+	if len(missingSet) > 0 {
+		missing := make([]string, 0, len(missingSet))
+		for name := range missingSet {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+		return "", missing
+	}
+
+	return expanded, nil
+}
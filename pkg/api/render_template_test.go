@@ -0,0 +1,99 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+)
+
+func TestExpandRenderTemplate(t *testing.T) {
+	const template = "d/{uid}/_?orgId=1&from={from}&to={to}&var-host={host}"
+
+	t.Run("should substitute the parameters", func(t *testing.T) {
+		path, missing := expandRenderTemplate(template, url.Values{
+			"uid":  {"abc"},
+			"from": {"now-7d"},
+			"to":   {"now"},
+			"host": {"web 1&2"},
+		})
+		require.Empty(t, missing)
+		assert.Equal(t, "d/abc/_?orgId=1&from=now-7d&to=now&var-host=web+1%262", path)
+	})
+
+	t.Run("should escape path parameters", func(t *testing.T) {
+		path, missing := expandRenderTemplate("d/{uid}/_", url.Values{"uid": {"../admin?x"}})
+		require.Empty(t, missing)
+		assert.Equal(t, "d/..%2Fadmin%3Fx/_", path)
+	})
+
+	t.Run("should substitute a placeholder used more than once", func(t *testing.T) {
+		path, missing := expandRenderTemplate("d/{uid}/_?var-uid={uid}", url.Values{"uid": {"abc"}})
+		require.Empty(t, missing)
+		assert.Equal(t, "d/abc/_?var-uid=abc", path)
+	})
+
+	t.Run("should report the missing parameters", func(t *testing.T) {
+		path, missing := expandRenderTemplate(template, url.Values{"uid": {"abc"}, "to": {""}})
+		assert.Empty(t, path)
+		assert.Equal(t, []string{"from", "host", "to"}, missing)
+	})
+}
+
+func TestRenderTemplate(t *testing.T) {
+	render := func(t *testing.T, sc *renderScenarioContext, reqURL string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		scenario := setupScenarioContext(t, reqURL)
+		scenario.m.Get("/api/render/templates/:name", func(c *contextmodel.ReqContext) {
+			c.SignedInUser = sc.user
+			sc.hs.RenderTemplate(c)
+		})
+		scenario.req = httptest.NewRequest(http.MethodGet, reqURL, nil)
+		scenario.resp = httptest.NewRecorder()
+		scenario.exec()
+		return scenario.resp
+	}
+
+	setup := func(t *testing.T) *renderScenarioContext {
+		sc := setupRenderScenario(t)
+		sc.hs.Cfg.RendererURLTemplates = map[string]string{
+			"report": "d-solo/{uid}/_?panelId={panel}&from={from}&to=now&width=800&height=400",
+		}
+		return sc
+	}
+
+	t.Run("should render the substituted path", func(t *testing.T) {
+		sc := setup(t)
+		resp := render(t, sc, "/api/render/templates/report?uid=abc&panel=2&from=now-1h")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "d-solo/abc/_?panelId=2&from=now-1h&to=now&width=800&height=400", sc.opts.Path)
+		assert.Equal(t, 800, sc.opts.Width)
+		assert.Equal(t, 400, sc.opts.Height)
+	})
+
+	t.Run("should keep substituted values in their path segment", func(t *testing.T) {
+		sc := setup(t)
+		resp := render(t, sc, "/api/render/templates/report?uid=..%2F..%2Fadmin&panel=2&from=now-1h")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "d-solo/..%2F..%2Fadmin/_?panelId=2&from=now-1h&to=now&width=800&height=400", sc.opts.Path)
+	})
+
+	t.Run("should reject missing parameters", func(t *testing.T) {
+		sc := setup(t)
+		resp := render(t, sc, "/api/render/templates/report?uid=abc")
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "missing template parameters: from, panel")
+	})
+
+	t.Run("should return 404 for an unknown template", func(t *testing.T) {
+		sc := setup(t)
+		resp := render(t, sc, "/api/render/templates/unknown?uid=abc")
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
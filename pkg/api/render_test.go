@@ -0,0 +1,1025 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	pref "github.com/grafana/grafana/pkg/services/preference"
+	"github.com/grafana/grafana/pkg/services/preference/preftest"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web/webtest"
+)
+
+func TestRenderHandler(t *testing.T) {
+	t.Run("should pass maxDataPoints to the render opts", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&maxDataPoints=250")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, 250, sc.opts.MaxDataPoints)
+	})
+
+	t.Run("should leave maxDataPoints unset when omitted", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Zero(t, sc.opts.MaxDataPoints)
+	})
+
+	t.Run("should pass the interval to the render opts", func(t *testing.T) {
+		for _, value := range []string{"30s", "5m", "1h", "1d"} {
+			sc := setupRenderScenario(t)
+			resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&interval="+value)
+			require.Equal(t, http.StatusOK, resp.Code, value)
+			assert.Equal(t, value, sc.opts.Interval)
+		}
+	})
+
+	t.Run("should keep the panel interval when omitted", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, sc.opts.Interval)
+	})
+
+	t.Run("should reject an invalid interval", func(t *testing.T) {
+		for _, value := range []string{"5", "fast", "-5m", "0s"} {
+			sc := setupRenderScenario(t)
+			resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&interval="+value)
+			assert.Equal(t, http.StatusBadRequest, resp.Code, value)
+		}
+	})
+
+	t.Run("should capture only the viewport by default", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		sc.hs.Cfg.RendererDefaultImageHeight = 500
+		resp := sc.get(t, "/render/d/uid/slug")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, 500, sc.opts.Height)
+	})
+
+	t.Run("should capture the full page with fullPage=true", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		sc.renderService.EXPECT().IsCapabilitySupported(gomock.Any(), rendering.FullHeightImages).Return(nil)
+		resp := sc.get(t, "/render/d/uid/slug?fullPage=true")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, -1, sc.opts.Height)
+	})
+
+	t.Run("should reject fullPage if the renderer can't capture full pages", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		sc.renderService.EXPECT().IsCapabilitySupported(gomock.Any(), rendering.FullHeightImages).Return(errors.New("FullHeightImages unsupported"))
+		resp := sc.get(t, "/render/d/uid/slug?fullPage=true")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should reject fullPage combined with a height", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d/uid/slug?fullPage=true&height=300")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should reject an invalid fullPage", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d/uid/slug?fullPage=maybe")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should disable animations by default", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d/uid/slug")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.True(t, sc.opts.DisableAnimations)
+	})
+
+	t.Run("should keep animations with disableAnimations=false", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d/uid/slug?disableAnimations=false")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.False(t, sc.opts.DisableAnimations)
+	})
+
+	t.Run("should reject an invalid disableAnimations", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d/uid/slug?disableAnimations=sometimes")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should pass the legend override to the render opts", func(t *testing.T) {
+		for _, value := range []string{"hidden", "bottom", "right"} {
+			sc := setupRenderScenario(t)
+			resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&legend="+value)
+			require.Equal(t, http.StatusOK, resp.Code, value)
+			assert.Equal(t, value, sc.opts.Legend)
+		}
+	})
+
+	t.Run("should keep the panel legend when omitted", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, sc.opts.Legend)
+	})
+
+	t.Run("should reject an invalid legend", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&legend=left")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should pass the background color to the render opts", func(t *testing.T) {
+		for _, value := range []string{"white", "#fff", "#ffffff"} {
+			sc := setupRenderScenario(t)
+			resp := sc.get(t, "/render/d/uid/slug?bgColor="+url.QueryEscape(value))
+			require.Equal(t, http.StatusOK, resp.Code, value)
+			assert.Equal(t, value, sc.opts.BackgroundColor)
+		}
+	})
+
+	t.Run("should reject an invalid background color", func(t *testing.T) {
+		for _, value := range []string{"blurple", "#12", "ffffff"} {
+			sc := setupRenderScenario(t)
+			resp := sc.get(t, "/render/d/uid/slug?bgColor="+url.QueryEscape(value))
+			assert.Equal(t, http.StatusBadRequest, resp.Code, value)
+		}
+	})
+
+	t.Run("should reject invalid maxDataPoints", func(t *testing.T) {
+		for _, value := range []string{"0", "-1", "abc", "100001"} {
+			sc := setupRenderScenario(t)
+			resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&maxDataPoints="+value)
+			assert.Equal(t, http.StatusBadRequest, resp.Code, value)
+		}
+	})
+}
+
+func TestRenderHandlerFit(t *testing.T) {
+	t.Run("should letterbox the image into the requested dimensions", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		red := color.NRGBA{R: 255, A: 255}
+		img := image.NewNRGBA(image.Rect(0, 0, 300, 100))
+		draw.Draw(img, img.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+		sc.setRenderImage(t, img)
+
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&width=200&height=150&fit=contain&fitBackground=%23ffffff")
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		result, err := png.Decode(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 200, 150), result.Bounds())
+		assert.Equal(t, color.NRGBAModel.Convert(color.White), color.NRGBAModel.Convert(result.At(100, 5)))
+		assert.Equal(t, red, color.NRGBAModel.Convert(result.At(100, 75)))
+	})
+
+	t.Run("should reject an unknown fit", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&fit=cover")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should reject an invalid background", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&fit=contain&fitBackground=blurple")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should reject fit for PDF renders", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d/uid/slug?encoding=pdf&fit=contain")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestRenderHandlerCompression(t *testing.T) {
+	t.Run("should re-encode the image with the requested compression", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		sc.setRenderImage(t, image.NewNRGBA(image.Rect(0, 0, 20, 10)))
+
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&compression=9")
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		result, err := png.Decode(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 20, 10), result.Bounds())
+	})
+
+	t.Run("should reject an invalid compression", func(t *testing.T) {
+		for _, value := range []string{"-1", "10", "best"} {
+			sc := setupRenderScenario(t)
+			resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&compression="+value)
+			assert.Equal(t, http.StatusBadRequest, resp.Code, value)
+		}
+	})
+}
+
+func TestRenderHandlerMaxDimensions(t *testing.T) {
+	t.Run("should downscale the image to fit into the bounding box", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		sc.hs.Cfg.RendererDefaultImageWidth, sc.hs.Cfg.RendererDefaultImageHeight = 1000, 500
+		sc.setRenderImage(t, image.NewNRGBA(image.Rect(0, 0, 1000, 500)))
+
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&maxWidth=400&maxHeight=100")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, 1000, sc.opts.Width)
+		assert.Equal(t, 500, sc.opts.Height)
+		assert.Equal(t, "200", resp.Header().Get("X-Render-Width"))
+		assert.Equal(t, "100", resp.Header().Get("X-Render-Height"))
+
+		result, err := png.Decode(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 200, 100), result.Bounds())
+	})
+
+	t.Run("should not upscale an image that already fits", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		sc.setRenderImage(t, image.NewNRGBA(image.Rect(0, 0, 300, 100)))
+
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&maxWidth=600")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "300", resp.Header().Get("X-Render-Width"))
+		assert.Equal(t, "100", resp.Header().Get("X-Render-Height"))
+	})
+
+	t.Run("should reject a bounding box combined with explicit dimensions", func(t *testing.T) {
+		for _, query := range []string{"maxWidth=400&width=800", "maxHeight=100&height=300"} {
+			sc := setupRenderScenario(t)
+			resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&"+query)
+			assert.Equal(t, http.StatusBadRequest, resp.Code, query)
+		}
+	})
+
+	t.Run("should reject an invalid bound", func(t *testing.T) {
+		for _, query := range []string{"maxWidth=0", "maxHeight=-1", "maxWidth=wide"} {
+			sc := setupRenderScenario(t)
+			resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&"+query)
+			assert.Equal(t, http.StatusBadRequest, resp.Code, query)
+		}
+	})
+}
+
+func TestRenderHandlerGrayscale(t *testing.T) {
+	t.Run("should convert the image to grayscale", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		img := image.NewNRGBA(image.Rect(0, 0, 20, 10))
+		for y := 0; y < 10; y++ {
+			for x := 0; x < 20; x++ {
+				img.Set(x, y, color.NRGBA{R: uint8(x * 12), G: 200, B: uint8(y * 25), A: 255})
+			}
+		}
+		sc.setRenderImage(t, img)
+
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&grayscale=true")
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		result, err := png.Decode(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, img.Bounds(), result.Bounds())
+		for y := 0; y < 10; y++ {
+			for x := 0; x < 20; x++ {
+				r, g, b, _ := result.At(x, y).RGBA()
+				require.True(t, r == g && g == b, "pixel %d,%d isn't gray", x, y)
+			}
+		}
+	})
+
+	t.Run("should keep the colors by default", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "png", resp.Body.String())
+	})
+
+	t.Run("should reject grayscale for PDF renders", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d/uid/slug?encoding=pdf&grayscale=true")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should reject an invalid grayscale", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&grayscale=maybe")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestRenderHandlerOrgOverride(t *testing.T) {
+	const otherOrgID = testOrgID + 1
+
+	t.Run("should render in the requested org for Grafana admins", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		sc.user.IsGrafanaAdmin = true
+		sc.hs.orgService = &orgtest.FakeOrgService{ExpectedOrg: &org.Org{ID: otherOrgID}}
+
+		resp := sc.get(t, fmt.Sprintf("/render/d/uid/slug?renderOrgId=%d", otherOrgID))
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, int64(otherOrgID), sc.opts.OrgID)
+		assert.Equal(t, org.RoleAdmin, sc.opts.OrgRole)
+		assert.Equal(t, testUserID, sc.opts.UserID)
+		assert.Equal(t, fmt.Sprintf("d/uid/slug?orgId=%d", otherOrgID), sc.opts.Path)
+	})
+
+	t.Run("should render in the requested org without switching the user's org", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		server := SetupAPITestServer(t, func(hs *HTTPServer) {
+			hs.RenderService = sc.renderService
+			hs.preferenceService = preftest.NewPreferenceServiceFake()
+			hs.orgService = &orgtest.FakeOrgService{ExpectedOrg: &org.Org{ID: otherOrgID}}
+			hs.log = log.New("test")
+		})
+		userService := &usertest.FakeUserService{UpdateFn: func(ctx context.Context, cmd *user.UpdateUserCommand) error {
+			t.Errorf("the org of the user must not be switched to %d", *cmd.OrgID)
+			return nil
+		}}
+		server.Mux.Use(middleware.OrgRedirect(setting.NewCfg(), userService))
+
+		req := server.NewGetRequest(fmt.Sprintf("/render/d/uid/slug?orgId=%d&renderOrgId=%d", testOrgID, otherOrgID))
+		req = webtest.RequestWithSignedInUser(req, &user.SignedInUser{OrgID: testOrgID, UserID: testUserID, OrgRole: org.RoleViewer, IsGrafanaAdmin: true})
+		res, err := server.Send(req)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, int64(otherOrgID), sc.opts.OrgID)
+		assert.Equal(t, fmt.Sprintf("d/uid/slug?orgId=%d", otherOrgID), sc.opts.Path)
+	})
+
+	t.Run("should reject an invalid renderOrgId", func(t *testing.T) {
+		for _, value := range []string{"abc", "0", "-2", "1.5"} {
+			sc := setupRenderScenario(t)
+			sc.user.IsGrafanaAdmin = true
+			resp := sc.get(t, "/render/d/uid/slug?renderOrgId="+value)
+			assert.Equal(t, http.StatusBadRequest, resp.Code, value)
+		}
+	})
+
+	t.Run("should reject an org override by a non admin", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, fmt.Sprintf("/render/d/uid/slug?renderOrgId=%d", otherOrgID))
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("should return 404 for an unknown org", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		sc.user.IsGrafanaAdmin = true
+		sc.hs.orgService = &orgtest.FakeOrgService{ExpectedError: org.ErrOrgNotFound}
+
+		resp := sc.get(t, fmt.Sprintf("/render/d/uid/slug?renderOrgId=%d", otherOrgID))
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("should render in the user's org when it's requested explicitly", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, fmt.Sprintf("/render/d/uid/slug?renderOrgId=%d", testOrgID))
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, int64(testOrgID), sc.opts.OrgID)
+		assert.Equal(t, org.RoleViewer, sc.opts.OrgRole)
+	})
+}
+
+func TestRenderHandlerEvents(t *testing.T) {
+	sc := setupRenderScenario(t)
+	req, err := http.NewRequest(http.MethodGet, "/render/d-solo/uid/slug?panelId=1", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp := sc.send(t, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "text/event-stream", resp.Header().Get("Content-Type"))
+
+	expected := "event: progress\ndata: {\"phase\":\"queued\"}\n\n" +
+		"event: progress\ndata: {\"phase\":\"rendering\"}\n\n" +
+		"event: complete\ndata: {\"phase\":\"completed\",\"contentType\":\"image/png\",\"image\":\"" + base64.StdEncoding.EncodeToString([]byte("png")) + "\"}\n\n"
+	assert.Equal(t, expected, resp.Body.String())
+
+	t.Run("should reject the parameters events can't carry", func(t *testing.T) {
+		for _, query := range []string{"sizes=100x100", "format=json", "diagnostics=true", "noDataPlaceholder=true"} {
+			sc := setupRenderScenario(t)
+			sc.user.IsGrafanaAdmin = true
+			req, err := http.NewRequest(http.MethodGet, "/render/d-solo/uid/slug?panelId=1&"+query, nil)
+			require.NoError(t, err)
+			req.Header.Set("Accept", "text/event-stream")
+
+			resp := sc.send(t, req)
+			assert.Equal(t, http.StatusBadRequest, resp.Code, query)
+			assert.Contains(t, resp.Body.String(), "text/event-stream", query)
+		}
+	})
+}
+
+func TestSanitizeAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		expected string
+	}{
+		{
+			name:     "keeps well-formed language ranges",
+			values:   []string{"en-US,en;q=0.9,*;q=0.5"},
+			expected: "en-US, en;q=0.9, *;q=0.5",
+		},
+		{
+			name:     "merges multiple header values",
+			values:   []string{"fr-CH", "de;q=0.7"},
+			expected: "fr-CH, de;q=0.7",
+		},
+		{
+			name:     "drops malformed tags and weights",
+			values:   []string{"en-US, not a tag!, de;q=2, fr;level=1, es;q=0.3"},
+			expected: "en-US, es;q=0.3",
+		},
+		{
+			name:     "drops header injection attempts",
+			values:   []string{"en\r\nX-Injected: true"},
+			expected: "",
+		},
+		{
+			name:     "drops oversized values",
+			values:   []string{strings.Repeat("en-US,", 100)},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sanitizeAcceptLanguage(tt.values))
+		})
+	}
+
+	t.Run("should forward the sanitized header to the renderer", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		req, err := http.NewRequest(http.MethodGet, "/render/d-solo/uid/slug?panelId=1", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Language", "en-US,<script>,de;q=0.5")
+
+		resp := sc.send(t, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, []string{"en-US, de;q=0.5"}, sc.opts.Headers["Accept-Language"])
+	})
+}
+
+func TestMergeAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		orgLanguage    string
+		expected       string
+	}{
+		{
+			name:     "returns an empty header without languages",
+			expected: "",
+		},
+		{
+			name:           "keeps the client languages without an org default",
+			acceptLanguage: "en-US, en;q=0.9",
+			expected:       "en-US, en;q=0.9",
+		},
+		{
+			name:        "uses the org default without client languages",
+			orgLanguage: "de-DE",
+			expected:    "de-DE",
+		},
+		{
+			name:           "appends the org default after the client languages",
+			acceptLanguage: "fr-CH, fr;q=0.8",
+			orgLanguage:    "de-DE",
+			expected:       "fr-CH, fr;q=0.8, de-DE;q=0.7",
+		},
+		{
+			name:           "orders the client languages by quality",
+			acceptLanguage: "en;q=0.5, fr-CH, de;q=0.9",
+			orgLanguage:    "es",
+			expected:       "fr-CH, de;q=0.9, en;q=0.5, es;q=0.4",
+		},
+		{
+			name:           "deduplicates languages keeping the highest quality",
+			acceptLanguage: "en;q=0.3, fr, EN;q=0.6",
+			orgLanguage:    "fr",
+			expected:       "fr, en;q=0.6",
+		},
+		{
+			name:           "keeps the org default below low client qualities",
+			acceptLanguage: "en;q=0.05",
+			orgLanguage:    "de",
+			expected:       "en;q=0.05, de;q=0.025",
+		},
+		{
+			name:           "ignores an invalid org default",
+			acceptLanguage: "en",
+			orgLanguage:    "not a language",
+			expected:       "en",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, mergeAcceptLanguage(tt.acceptLanguage, tt.orgLanguage))
+		})
+	}
+
+	t.Run("should forward the merged header to the renderer", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		prefService := preftest.NewPreferenceServiceFake()
+		prefService.ExpectedPreference = &pref.Preference{JSONData: &pref.PreferenceJSONData{Language: "de-DE"}}
+		sc.hs.preferenceService = prefService
+
+		req, err := http.NewRequest(http.MethodGet, "/render/d-solo/uid/slug?panelId=1", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Language", "en;q=0.8,en-US")
+
+		resp := sc.send(t, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, []string{"en-US, en;q=0.8, de-DE;q=0.7"}, sc.opts.Headers["Accept-Language"])
+	})
+}
+
+func TestRenderHandlerJSON(t *testing.T) {
+	t.Run("should wrap the image in a JSON envelope", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&format=json")
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var body renderJSONResult
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		assert.Equal(t, "image/png", body.ContentType)
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("png")), body.Image)
+		assert.Empty(t, body.Annotations)
+	})
+
+	t.Run("should reject an unknown format", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&format=xml")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should require format=json for the annotation legend", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=1&includeAnnotationLegend=true")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should list the dashboard annotations in the time range", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardService.On("GetDashboard", mock.Anything, &dashboards.GetDashboardQuery{UID: "uid", OrgID: testOrgID}).
+			Return(&dashboards.Dashboard{ID: 5, UID: "uid", OrgID: testOrgID}, nil)
+		repo := &fakeRenderAnnotationsRepo{items: []*annotations.ItemDTO{
+			{Time: 1000, TimeEnd: 2000, Text: "deploy", Tags: []string{"release"}},
+			{Time: 1500, Text: "incident"},
+		}}
+		sc.hs.DashboardService = dashboardService
+		sc.hs.annotationsRepo = repo
+		guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanViewValue: true})
+
+		resp := sc.get(t, "/render/d/uid/slug?from=1000&to=3000&format=json&includeAnnotationLegend=true")
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var body renderJSONResult
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		assert.Equal(t, []renderAnnotationEntry{
+			{Time: 1000, TimeEnd: 2000, Text: "deploy", Tags: []string{"release"}},
+			{Time: 1500, Text: "incident"},
+		}, body.Annotations)
+
+		require.NotNil(t, repo.query)
+		assert.Equal(t, int64(5), repo.query.DashboardID)
+		assert.Equal(t, int64(1000), repo.query.From)
+		assert.Equal(t, int64(3000), repo.query.To)
+		assert.Equal(t, int64(maxRenderAnnotationLegendItems), repo.query.Limit)
+	})
+
+	t.Run("should return 404 for the annotation legend of an unknown dashboard", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(nil, dashboards.ErrDashboardNotFound)
+		sc.hs.DashboardService = dashboardService
+
+		resp := sc.get(t, "/render/d/unknown/slug?format=json&includeAnnotationLegend=true")
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestRenderHandlerPanelData(t *testing.T) {
+	dashboardJSON := simplejson.NewFromAny(map[string]any{
+		"templating": map[string]any{"list": []any{
+			map[string]any{"name": "job", "type": "custom", "current": map[string]any{"value": "api"}},
+			map[string]any{"name": "instance", "type": "query", "includeAll": true, "current": map[string]any{"value": "$__all"}},
+		}},
+		"panels": []any{
+			map[string]any{"id": 1, "type": "timeseries"},
+			map[string]any{
+				"id":         4,
+				"type":       "timeseries",
+				"datasource": map[string]any{"uid": "prom", "type": "prometheus"},
+				"timeFrom":   "2h",
+				"timeShift":  "1h",
+				"interval":   "1m",
+				"targets": []any{
+					map[string]any{"refId": "A", "expr": "rate(up[$__rate_interval])"},
+				},
+			},
+			map[string]any{
+				"id":         5,
+				"type":       "timeseries",
+				"datasource": map[string]any{"uid": "prom", "type": "prometheus"},
+				"targets": []any{
+					map[string]any{"refId": "A", "expr": `up{job="${job}", instance=~"$instance"}`},
+				},
+			},
+			map[string]any{
+				"id":        2,
+				"type":      "row",
+				"collapsed": true,
+				"panels": []any{
+					map[string]any{
+						"id":         3,
+						"type":       "timeseries",
+						"datasource": map[string]any{"uid": "prom"},
+						"targets": []any{
+							map[string]any{"refId": "A", "expr": "up"},
+							map[string]any{"refId": "B", "expr": "down", "hide": true},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	setup := func(t *testing.T) (*renderScenarioContext, *query.FakeQueryService) {
+		sc := setupRenderScenario(t)
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardService.On("GetDashboard", mock.Anything, &dashboards.GetDashboardQuery{UID: "uid", OrgID: testOrgID}).
+			Return(&dashboards.Dashboard{ID: 5, UID: "uid", OrgID: testOrgID, Data: dashboardJSON}, nil)
+		queryService := &query.FakeQueryService{}
+		sc.hs.DashboardService = dashboardService
+		sc.hs.queryDataService = queryService
+		guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanViewValue: true})
+		return sc, queryService
+	}
+
+	t.Run("should return the panel data next to the image", func(t *testing.T) {
+		sc, queryService := setup(t)
+		queryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.MatchedBy(func(req dtos.MetricRequest) bool {
+			return req.From == "now-1h" && req.To == "now" && len(req.Queries) == 1 &&
+				req.Queries[0].Get("refId").MustString() == "A" &&
+				req.Queries[0].Get("datasource").Get("uid").MustString() == "prom"
+		})).Return(&backend.QueryDataResponse{Responses: backend.Responses{"A": backend.DataResponse{}}}, nil)
+
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=3&from=now-1h&to=now&format=json&includeData=true")
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		assert.NotEmpty(t, body["image"])
+		assert.Contains(t, body["data"], "results")
+		queryService.AssertExpectations(t)
+	})
+
+	t.Run("should query the panel like the frontend", func(t *testing.T) {
+		sc, queryService := setup(t)
+		queryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.MatchedBy(func(req dtos.MetricRequest) bool {
+			from, _ := strconv.ParseInt(req.From, 10, 64)
+			to, _ := strconv.ParseInt(req.To, 10, 64)
+			return to-from == (2*time.Hour).Milliseconds() && len(req.Queries) == 1 &&
+				req.Queries[0].Get("expr").MustString() == "rate(up[$__rate_interval])" &&
+				req.Queries[0].Get("datasource").Get("uid").MustString() == "prom" &&
+				req.Queries[0].Get("maxDataPoints").MustInt64() == 1000 &&
+				req.Queries[0].Get("intervalMs").MustInt64() == time.Minute.Milliseconds()
+		})).Return(&backend.QueryDataResponse{Responses: backend.Responses{"A": backend.DataResponse{}}}, nil)
+
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=panel-4&width=1000&format=json&includeData=true")
+		require.Equal(t, http.StatusOK, resp.Code)
+		queryService.AssertExpectations(t)
+	})
+
+	t.Run("should reject panel data of panels whose queries use template variables", func(t *testing.T) {
+		sc, queryService := setup(t)
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=5&var-job=db&format=json&includeData=true")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "template variable")
+		queryService.AssertNotCalled(t, "QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should query a finer interval than the computed one as a min interval", func(t *testing.T) {
+		for value, want := range map[string]time.Duration{"10s": 20 * time.Second, "1m": time.Minute} {
+			sc, queryService := setup(t)
+			queryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+				Return(&backend.QueryDataResponse{Responses: backend.Responses{"A": backend.DataResponse{}}}, nil)
+
+			// 6h spread over 1000 data points is rounded to a computed interval of 20s
+			resp := sc.get(t, "/render/d-solo/uid/slug?panelId=3&from=now-6h&to=now&width=1000&interval="+value+"&format=json&includeData=true")
+			require.Equal(t, http.StatusOK, resp.Code, value)
+			query := queryService.Calls[0].Arguments.Get(3).(dtos.MetricRequest).Queries[0]
+			assert.Equal(t, want.Milliseconds(), query.Get("intervalMs").MustInt64(), value)
+		}
+	})
+
+	t.Run("should query the exact interval with enough max data points", func(t *testing.T) {
+		sc, queryService := setup(t)
+		queryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(&backend.QueryDataResponse{Responses: backend.Responses{"A": backend.DataResponse{}}}, nil)
+
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=3&from=now-6h&to=now&width=1000&interval=10s&maxDataPoints=2160&format=json&includeData=true")
+		require.Equal(t, http.StatusOK, resp.Code)
+		query := queryService.Calls[0].Arguments.Get(3).(dtos.MetricRequest).Queries[0]
+		assert.Equal(t, (10 * time.Second).Milliseconds(), query.Get("intervalMs").MustInt64())
+	})
+
+	t.Run("should return 404 for an unknown panel", func(t *testing.T) {
+		sc, _ := setup(t)
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=42&format=json&includeData=true")
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("should reject panel data for dashboard renders", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d/uid/slug?panelId=3&format=json&includeData=true")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should reject panel data without format=json", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		resp := sc.get(t, "/render/d-solo/uid/slug?panelId=3&includeData=true")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestRenderHandlerTagServerURLs(t *testing.T) {
+	setup := func(t *testing.T) *renderScenarioContext {
+		sc := setupRenderScenario(t)
+		sc.hs.Cfg.RendererTagServerUrls = map[string]string{"heavy": "http://renderer-heavy:8081/render"}
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardService.On("GetDashboard", mock.Anything, &dashboards.GetDashboardQuery{UID: "heavy", OrgID: testOrgID}).
+			Return(&dashboards.Dashboard{UID: "heavy", Data: simplejson.NewFromAny(map[string]any{"tags": []any{"team-a", "heavy"}})}, nil).Maybe()
+		dashboardService.On("GetDashboard", mock.Anything, &dashboards.GetDashboardQuery{UID: "light", OrgID: testOrgID}).
+			Return(&dashboards.Dashboard{UID: "light", Data: simplejson.NewFromAny(map[string]any{"tags": []any{"team-a"}})}, nil).Maybe()
+		dashboardService.On("GetDashboard", mock.Anything, &dashboards.GetDashboardQuery{UID: "unknown", OrgID: testOrgID}).
+			Return(nil, dashboards.ErrDashboardNotFound).Maybe()
+		sc.hs.DashboardService = dashboardService
+		return sc
+	}
+
+	t.Run("should select the renderer of a matching dashboard tag", func(t *testing.T) {
+		sc := setup(t)
+		resp := sc.get(t, "/render/d/heavy/slug")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "http://renderer-heavy:8081/render", sc.opts.ServerURL)
+	})
+
+	t.Run("should use the default renderer when no tag matches", func(t *testing.T) {
+		sc := setup(t)
+		resp := sc.get(t, "/render/d-solo/light/slug?panelId=1")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, sc.opts.ServerURL)
+	})
+
+	t.Run("should return 404 when the dashboard can't be resolved", func(t *testing.T) {
+		sc := setup(t)
+		resp := sc.get(t, "/render/d/unknown/slug")
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestRenderHandlerClientCanceled(t *testing.T) {
+	sc := setupRenderScenario(t)
+	renderService := rendering.NewMockService(gomock.NewController(t))
+	sc.hs.RenderService = renderService
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var renderCtx context.Context
+	renderService.EXPECT().Render(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ rendering.RenderType, _ rendering.Opts, _ rendering.Session) (*rendering.RenderResult, error) {
+			renderCtx = ctx
+			// the client disconnects while rendering
+			cancel()
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/render/d/uid/slug", nil)
+	require.NoError(t, err)
+
+	resp := sc.send(t, req)
+	assert.Equal(t, 499, resp.Code)
+	require.NotNil(t, renderCtx)
+	assert.ErrorIs(t, renderCtx.Err(), context.Canceled)
+}
+
+func TestRenderHandlerMetricsLabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		label    string
+		expected string
+	}{
+		{name: "should pass an allowed label to the render opts", label: "report-type", expected: "report-type"},
+		{name: "should ignore a label that isn't allowed", label: "user-1234", expected: ""},
+		{name: "should render without a label", label: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := setupRenderScenario(t)
+			sc.hs.Cfg.RendererMetricsLabels = []string{"report-type", "alert-image"}
+
+			req, err := http.NewRequest(http.MethodGet, "/render/d-solo/uid/slug?panelId=1", nil)
+			require.NoError(t, err)
+			if tt.label != "" {
+				req.Header.Set("X-Render-Label", tt.label)
+			}
+
+			resp := sc.send(t, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, tt.expected, sc.opts.MetricsLabel)
+		})
+	}
+}
+
+func TestGetRenderLoad(t *testing.T) {
+	t.Run("should return the render load", func(t *testing.T) {
+		sc := setupRenderScenario(t)
+		renderService := rendering.NewMockService(gomock.NewController(t))
+		renderService.EXPECT().LoadStats().Return(rendering.LoadStats{InFlight: 5, Limit: 30, Rejected: 3})
+		sc.hs.RenderService = renderService
+
+		resp := sc.hs.GetRenderLoad(&contextmodel.ReqContext{})
+		require.Equal(t, http.StatusOK, resp.Status())
+		assert.JSONEq(t, `{"inFlight":5,"limit":30,"rejected":3}`, string(resp.Body()))
+	})
+
+	t.Run("should be restricted to Grafana admins", func(t *testing.T) {
+		for _, isGrafanaAdmin := range []bool{false, true} {
+			renderService := rendering.NewMockService(gomock.NewController(t))
+			renderService.EXPECT().LoadStats().Return(rendering.LoadStats{}).MaxTimes(1)
+			server := SetupAPITestServer(t, func(hs *HTTPServer) {
+				hs.RenderService = renderService
+			})
+
+			req := webtest.RequestWithSignedInUser(server.NewGetRequest("/api/render/load"), &user.SignedInUser{OrgID: testOrgID, UserID: testUserID, OrgRole: org.RoleAdmin, IsGrafanaAdmin: isGrafanaAdmin})
+			res, err := server.Send(req)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+			if isGrafanaAdmin {
+				assert.Equal(t, http.StatusOK, res.StatusCode)
+			} else {
+				assert.Equal(t, http.StatusForbidden, res.StatusCode)
+			}
+		}
+	})
+}
+
+func TestRenderErrorTitle(t *testing.T) {
+	req := renderRequest{Opts: rendering.Opts{CommonOpts: rendering.CommonOpts{Path: "d/uid/slug?from=now-6h"}}}
+	admin := &user.SignedInUser{OrgID: testOrgID, UserID: testUserID, IsGrafanaAdmin: true}
+	viewer := &user.SignedInUser{OrgID: testOrgID, UserID: testUserID, OrgRole: org.RoleViewer}
+	orgAdmin := &user.SignedInUser{OrgID: testOrgID, UserID: testUserID, OrgRole: org.RoleAdmin}
+
+	t.Run("should show the attempted path to Grafana admins", func(t *testing.T) {
+		hs := &HTTPServer{Cfg: &setting.Cfg{RendererDebugErrors: true}}
+		assert.Equal(t, "Rendering failed. Attempted path: d/uid/slug?from=now-6h", hs.renderErrorTitle(admin, req, "Rendering failed."))
+	})
+
+	t.Run("should never show the attempted path to non admins", func(t *testing.T) {
+		hs := &HTTPServer{Cfg: &setting.Cfg{RendererDebugErrors: true}}
+		for _, u := range []*user.SignedInUser{viewer, orgAdmin} {
+			assert.Equal(t, "Rendering failed.", hs.renderErrorTitle(u, req, "Rendering failed."))
+		}
+	})
+
+	t.Run("should not show the attempted path when disabled", func(t *testing.T) {
+		hs := &HTTPServer{Cfg: &setting.Cfg{}}
+		assert.Equal(t, "Rendering failed.", hs.renderErrorTitle(admin, req, "Rendering failed."))
+	})
+}
+
+func TestGetRenderDashboardUID(t *testing.T) {
+	assert.Equal(t, "uid", getRenderDashboardUID("d/uid/slug"))
+	assert.Equal(t, "uid", getRenderDashboardUID("d-solo/uid/slug?panelId=1"))
+	assert.Equal(t, "uid", getRenderDashboardUID("/d/uid"))
+	assert.Empty(t, getRenderDashboardUID("explore?left=1"))
+	assert.Empty(t, getRenderDashboardUID("d"))
+}
+
+type fakeRenderAnnotationsRepo struct {
+	annotations.Repository
+	items []*annotations.ItemDTO
+	query *annotations.ItemQuery
+}
+
+func (r *fakeRenderAnnotationsRepo) Find(_ context.Context, query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
+	r.query = query
+	return r.items, nil
+}
+
+type renderScenarioContext struct {
+	hs            *HTTPServer
+	renderService *rendering.MockService
+	user          *user.SignedInUser
+	// opts holds the options of the last call to the render service.
+	opts rendering.Opts
+	// filePath is the file the render service returns as its result.
+	filePath string
+}
+
+// setupRenderScenario sets up a render handler backed by a mocked render
+// service that records the options it's called with.
+func setupRenderScenario(t *testing.T) *renderScenarioContext {
+	t.Helper()
+
+	filePath := filepath.Join(t.TempDir(), "render.png")
+	require.NoError(t, os.WriteFile(filePath, []byte("png"), 0600))
+
+	cfg := setting.NewCfg()
+	cfg.ErrTemplateName = "error"
+
+	sc := &renderScenarioContext{
+		renderService: rendering.NewMockService(gomock.NewController(t)),
+		user:          &user.SignedInUser{OrgID: testOrgID, UserID: testUserID, OrgRole: org.RoleViewer},
+		filePath:      filePath,
+	}
+	sc.hs = &HTTPServer{
+		Cfg:               cfg,
+		RenderService:     sc.renderService,
+		preferenceService: preftest.NewPreferenceServiceFake(),
+		log:               log.New("test"),
+	}
+	sc.renderService.EXPECT().Render(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ rendering.RenderType, opts rendering.Opts, _ rendering.Session) (*rendering.RenderResult, error) {
+			sc.opts = opts
+			if opts.OnProgress != nil {
+				opts.OnProgress(rendering.RenderPhaseQueued)
+				opts.OnProgress(rendering.RenderPhaseRendering)
+			}
+			return &rendering.RenderResult{FilePath: filePath}, nil
+		}).AnyTimes()
+
+	return sc
+}
+
+// setRenderImage makes the render service return img as a PNG.
+func (sc *renderScenarioContext) setRenderImage(t *testing.T, img image.Image) {
+	t.Helper()
+
+	f, err := os.Create(sc.filePath)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, img))
+	require.NoError(t, f.Close())
+}
+
+func (sc *renderScenarioContext) get(t *testing.T, url string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	return sc.send(t, req)
+}
+
+func (sc *renderScenarioContext) send(t *testing.T, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+
+	scenario := setupScenarioContext(t, req.URL.String())
+	handler := func(c *contextmodel.ReqContext) {
+		c.SignedInUser = sc.user
+		sc.hs.RenderHandler(c)
+	}
+	scenario.m.Get("/render/*", handler)
+	scenario.m.Post("/render/*", handler)
+	scenario.req = req
+	scenario.resp = httptest.NewRecorder()
+	scenario.exec()
+
+	return scenario.resp
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// renderTokenQueryParam is the query parameter a render token is passed in.
+const renderTokenQueryParam = "renderToken"
+
+var errInvalidRenderToken = errors.New("invalid render token")
+
+// renderToken is a single-use grant to render a given path as the user that minted it.
+type renderToken struct {
+	OrgID   int64        `json:"orgId"`
+	UserID  int64        `json:"userId"`
+	OrgRole org.RoleType `json:"orgRole"`
+	// Path is the normalized render path, including the render parameters.
+	Path      string    `json:"path"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type createRenderTokenCommand struct {
+	// Path is the dashboard render path, such as d-solo/<uid>/<slug>?panelId=1&width=1000.
+	Path string `json:"path"`
+}
+
+type renderTokenResult struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// CreateRenderToken mints a render token that allows rendering the given
+// dashboard path once, with exactly the given parameters, without further
+// authentication.
+func (hs *HTTPServer) CreateRenderToken(c *contextmodel.ReqContext) {
+	cmd := createRenderTokenCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		c.JsonApiErr(http.StatusBadRequest, "bad request data", err)
+		return
+	}
+
+	path, err := normalizeRenderPath(cmd.Path)
+	if err != nil {
+		c.JsonApiErr(http.StatusBadRequest, "Invalid render path", err)
+		return
+	}
+
+	if _, errResp := hs.getRenderDashboard(c, getRenderDashboardUID(path)); errResp != nil {
+		errResp.WriteTo(c)
+		return
+	}
+
+	userID, err := identity.UserIdentifier(c.SignedInUser.GetNamespacedID())
+	if err != nil {
+		c.JsonApiErr(http.StatusForbidden, "Render tokens can only be created by users and service accounts", err)
+		return
+	}
+
+	token, err := util.GetRandomString(32)
+	if err != nil {
+		c.JsonApiErr(http.StatusInternalServerError, "Failed to create render token", err)
+		return
+	}
+
+	rt := renderToken{
+		OrgID:     c.SignedInUser.GetOrgID(),
+		UserID:    userID,
+		OrgRole:   c.SignedInUser.GetOrgRole(),
+		Path:      path,
+		ExpiresAt: time.Now().Add(hs.Cfg.RendererRenderTokenLifetime),
+	}
+	data, err := json.Marshal(rt)
+	if err != nil {
+		c.JsonApiErr(http.StatusInternalServerError, "Failed to create render token", err)
+		return
+	}
+
+	if err := hs.RemoteCacheService.Set(c.Req.Context(), renderTokenCacheKey(token), data, hs.Cfg.RendererRenderTokenLifetime); err != nil {
+		c.JsonApiErr(http.StatusInternalServerError, "Failed to create render token", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, renderTokenResult{Token: token, ExpiresAt: rt.ExpiresAt})
+}
+
+// renderTokenAuth signs in render requests carrying a render token as the
+// user that minted the token. Requests without a token are left to the
+// regular authentication.
+func (hs *HTTPServer) renderTokenAuth(c *contextmodel.ReqContext) {
+	token := c.Query(renderTokenQueryParam)
+	if token == "" {
+		return
+	}
+
+	// the token binds the render path and query, not the request body, so
+	// bodies, which could change the render with field overrides, are
+	// rejected before the token is consumed
+	if hasRenderRequestBody(c.Req) {
+		c.JsonApiErr(http.StatusBadRequest, "Render tokens can't be used with a request body, pass fieldOverrides in the query instead", nil)
+		return
+	}
+
+	rt, err := hs.consumeRenderToken(c.Req.Context(), token)
+	if err != nil {
+		if errors.Is(err, errInvalidRenderToken) {
+			c.JsonApiErr(http.StatusUnauthorized, "Invalid render token", nil)
+			return
+		}
+		c.JsonApiErr(http.StatusInternalServerError, "Failed to verify render token", err)
+		return
+	}
+
+	path, err := normalizeRenderPath(web.Params(c.Req)["*"] + "?" + c.Req.URL.RawQuery)
+	if err != nil || path != rt.Path {
+		c.JsonApiErr(http.StatusUnauthorized, "Render token is not valid for this render", nil)
+		return
+	}
+
+	c.SignedInUser = &user.SignedInUser{OrgID: rt.OrgID, UserID: rt.UserID, OrgRole: rt.OrgRole}
+	c.IsSignedIn = true
+}
+
+// consumeRenderToken returns the render token and removes it, so that it
+// can't be used again. The token is taken from the remote cache in a single
+// operation, so that of concurrent requests, even to different Grafana
+// instances, only one gets it.
+func (hs *HTTPServer) consumeRenderToken(ctx context.Context, token string) (*renderToken, error) {
+	data, err := hs.RemoteCacheService.GetAndDelete(ctx, renderTokenCacheKey(token))
+	if err != nil {
+		if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			return nil, errInvalidRenderToken
+		}
+		return nil, err
+	}
+
+	rt := &renderToken{}
+	if err := json.Unmarshal(data, rt); err != nil {
+		return nil, err
+	}
+
+	// the cache only expires items with a precision of seconds
+	if time.Now().After(rt.ExpiresAt) {
+		return nil, errInvalidRenderToken
+	}
+
+	return rt, nil
+}
+
+func renderTokenCacheKey(token string) string {
+	return fmt.Sprintf("render-token-%s", token)
+}
+
+// normalizeRenderPath returns the render path with its query parameters
+// sorted and without a render token, so that equivalent paths compare equal.
+func normalizeRenderPath(path string) (string, error) {
+	path, rawQuery, _ := strings.Cut(path, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", err
+	}
+	query.Del(renderTokenQueryParam)
+
+	path = strings.Trim(path, "/")
+	if len(query) == 0 {
+		return path, nil
+	}
+	return path + "?" + query.Encode(), nil
+}
+
+// hasRenderRequestBody tells whether a render request has a body. Bodies of
+// unknown length are peeked at and left readable.
+func hasRenderRequestBody(req *http.Request) bool {
+	if req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0 {
+		return false
+	}
+	if req.ContentLength > 0 {
+		return true
+	}
+
+	var b [1]byte
+	n, _ := io.ReadFull(req.Body, b[:])
+	req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(b[:n]), req.Body))
+	return n > 0
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/middleware"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/org"
+)
+
+func TestRenderToken(t *testing.T) {
+	setup := func(t *testing.T) *renderScenarioContext {
+		sc := setupRenderScenario(t)
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardService.On("GetDashboard", mock.Anything, &dashboards.GetDashboardQuery{UID: "uid", OrgID: testOrgID}).
+			Return(&dashboards.Dashboard{ID: 5, UID: "uid", OrgID: testOrgID}, nil).Maybe()
+		dashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(nil, dashboards.ErrDashboardNotFound).Maybe()
+		sc.hs.DashboardService = dashboardService
+		sc.hs.RemoteCacheService = remotecache.NewFakeStore(t)
+		sc.hs.Cfg.RendererRenderTokenLifetime = time.Minute
+		guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanViewValue: true})
+		return sc
+	}
+
+	t.Run("should render once with the token instead of a session", func(t *testing.T) {
+		sc := setup(t)
+		token := sc.mintRenderToken(t, "d-solo/uid/slug?panelId=1&width=800")
+
+		resp := sc.getWithRenderToken(t, "/render/d-solo/uid/slug?width=800&panelId=1&renderToken="+token)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, testOrgID, sc.opts.OrgID)
+		assert.Equal(t, testUserID, sc.opts.UserID)
+		assert.Equal(t, org.RoleViewer, sc.opts.OrgRole)
+		assert.NotContains(t, sc.opts.Path, token)
+
+		resp = sc.getWithRenderToken(t, "/render/d-solo/uid/slug?width=800&panelId=1&renderToken="+token)
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("should render with field overrides bound into the token", func(t *testing.T) {
+		sc := setup(t)
+		overrides := url.QueryEscape(`[{"matcher":{"id":"byName","options":"cpu"},"properties":[{"id":"unit","value":"percent"}]}]`)
+		token := sc.mintRenderToken(t, "d-solo/uid/slug?panelId=1&fieldOverrides="+overrides)
+
+		req := httptest.NewRequest(http.MethodPost, "/render/d-solo/uid/slug?panelId=1&fieldOverrides="+overrides+"&renderToken="+token, nil)
+		resp := sc.sendWithRenderToken(t, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, sc.opts.FieldOverrides, `"percent"`)
+	})
+
+	t.Run("should reject a body, which the token doesn't bind", func(t *testing.T) {
+		sc := setup(t)
+		token := sc.mintRenderToken(t, "d-solo/uid/slug?panelId=1")
+
+		body := `{"fieldOverrides":[{"matcher":{"id":"byName","options":"cpu"},"properties":[{"id":"unit","value":"percent"}]}]}`
+		req := httptest.NewRequest(http.MethodPost, "/render/d-solo/uid/slug?panelId=1&renderToken="+token, strings.NewReader(body))
+		resp := sc.sendWithRenderToken(t, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		// the rejected request doesn't consume the token
+		resp = sc.getWithRenderToken(t, "/render/d-solo/uid/slug?panelId=1&renderToken="+token)
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("should reject a token for other render parameters", func(t *testing.T) {
+		sc := setup(t)
+		token := sc.mintRenderToken(t, "d-solo/uid/slug?panelId=1&width=800")
+
+		resp := sc.getWithRenderToken(t, "/render/d-solo/uid/slug?panelId=2&width=800&renderToken="+token)
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("should reject an expired token", func(t *testing.T) {
+		sc := setup(t)
+		sc.hs.Cfg.RendererRenderTokenLifetime = time.Nanosecond
+		token := sc.mintRenderToken(t, "d/uid/slug")
+
+		resp := sc.getWithRenderToken(t, "/render/d/uid/slug?renderToken="+token)
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("should reject an unknown token", func(t *testing.T) {
+		sc := setup(t)
+		resp := sc.getWithRenderToken(t, "/render/d/uid/slug?renderToken=unknown")
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("should not mint a token for an unknown dashboard", func(t *testing.T) {
+		sc := setup(t)
+		resp := sc.postRenderToken(t, "d/unknown/slug")
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("should not mint a token for a path that isn't a dashboard", func(t *testing.T) {
+		sc := setup(t)
+		resp := sc.postRenderToken(t, "explore?left=1")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestNormalizeRenderPath(t *testing.T) {
+	path, err := normalizeRenderPath("/d-solo/uid/slug?width=800&panelId=1&renderToken=abc")
+	require.NoError(t, err)
+	assert.Equal(t, "d-solo/uid/slug?panelId=1&width=800", path)
+
+	path, err = normalizeRenderPath("d/uid/slug")
+	require.NoError(t, err)
+	assert.Equal(t, "d/uid/slug", path)
+
+	_, err = normalizeRenderPath("d/uid/slug?a=%zz")
+	assert.Error(t, err)
+}
+
+func (sc *renderScenarioContext) postRenderToken(t *testing.T, path string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(createRenderTokenCommand{Path: path})
+	require.NoError(t, err)
+
+	scenario := setupScenarioContext(t, "/api/render/tokens")
+	scenario.m.Post("/api/render/tokens", func(c *contextmodel.ReqContext) {
+		c.SignedInUser = sc.user
+		sc.hs.CreateRenderToken(c)
+	})
+	scenario.req = httptest.NewRequest(http.MethodPost, "/api/render/tokens", strings.NewReader(string(body)))
+	scenario.req.Header.Set("Content-Type", "application/json")
+	scenario.resp = httptest.NewRecorder()
+	scenario.exec()
+
+	return scenario.resp
+}
+
+func (sc *renderScenarioContext) mintRenderToken(t *testing.T, path string) string {
+	t.Helper()
+
+	resp := sc.postRenderToken(t, path)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var result renderTokenResult
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+	require.NotEmpty(t, result.Token)
+
+	return result.Token
+}
+
+// getWithRenderToken sends a render request without a signed in user.
+func (sc *renderScenarioContext) getWithRenderToken(t *testing.T, url string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	return sc.sendWithRenderToken(t, httptest.NewRequest(http.MethodGet, url, nil))
+}
+
+// sendWithRenderToken sends a render request without a signed in user.
+func (sc *renderScenarioContext) sendWithRenderToken(t *testing.T, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+
+	scenario := setupScenarioContext(t, req.URL.String())
+	scenario.m.Get("/render/*", sc.hs.renderTokenAuth, middleware.ReqSignedIn, sc.hs.RenderHandler)
+	scenario.m.Post("/render/*", sc.hs.renderTokenAuth, middleware.ReqSignedIn, sc.hs.RenderHandler)
+	scenario.req = req
+	scenario.resp = httptest.NewRecorder()
+	scenario.exec()
+
+	return scenario.resp
+}
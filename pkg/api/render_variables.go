@@ -0,0 +1,84 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// renderVariablePattern matches template variable references the way the
+// frontend does: $name, [[name]], [[name:format]], ${name} and ${name:format}.
+var renderVariablePattern = regexp.MustCompile(`\$(\w+)|\[\[(\w+?)(?::(\w+))?\]\]|\$\{(\w+)(?:\.([^:^\}]+))?(?::([^\}]+))?\}`)
+
+// errRenderPanelVariable is returned for panels whose queries use a dashboard
+// template variable. Only the frontend interpolates template variables, from
+// the var-<name> query parameters of the render.
+type errRenderPanelVariable struct {
+	Name string
+}
+
+func (e errRenderPanelVariable) Error() string {
+	return fmt.Sprintf("the panel's queries use template variable %s", e.Name)
+}
+
+// getRenderPanelVariable returns the name of a dashboard template variable
+// used by the queries, data source, relative time, time shift or interval of
+// a panel, or an empty string if they don't use any. References to other
+// variables, such as $__interval, are left for the data source to replace.
+func getRenderPanelVariable(dashboard *simplejson.Json, panel *simplejson.Json) string {
+	names := map[string]bool{}
+	for _, item := range dashboard.GetPath("templating", "list").MustArray() {
+		if name := simplejson.NewFromAny(item).Get("name").MustString(); name != "" {
+			names[name] = true
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+
+	values := []any{
+		panel.Get("datasource").Interface(),
+		panel.Get("timeFrom").Interface(),
+		panel.Get("timeShift").Interface(),
+		panel.Get("interval").Interface(),
+	}
+	for _, target := range panel.Get("targets").MustArray() {
+		if !simplejson.NewFromAny(target).Get("hide").MustBool() {
+			values = append(values, target)
+		}
+	}
+
+	for _, value := range values {
+		if name := findRenderVariable(value, names); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+func findRenderVariable(value any, names map[string]bool) string {
+	switch value := value.(type) {
+	case string:
+		for _, groups := range renderVariablePattern.FindAllStringSubmatch(value, -1) {
+			for _, name := range []string{groups[1], groups[2], groups[4]} {
+				if names[name] {
+					return name
+				}
+			}
+		}
+	case map[string]any:
+		for _, item := range value {
+			if name := findRenderVariable(item, names); name != "" {
+				return name
+			}
+		}
+	case []any:
+		for _, item := range value {
+			if name := findRenderVariable(item, names); name != "" {
+				return name
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestGetRenderPanelVariable(t *testing.T) {
+	dashboard := simplejson.NewFromAny(map[string]any{
+		"templating": map[string]any{"list": []any{
+			map[string]any{"name": "job", "type": "custom"},
+			map[string]any{"name": "ds", "type": "datasource"},
+		}},
+	})
+
+	tests := []struct {
+		name  string
+		panel map[string]any
+		want  string
+	}{
+		{name: "no variables", panel: map[string]any{"targets": []any{map[string]any{"expr": "up"}}}, want: ""},
+		{name: "built-in variables", panel: map[string]any{"targets": []any{map[string]any{"expr": "rate(up[$__rate_interval])"}}}, want: ""},
+		{name: "unknown variables", panel: map[string]any{"targets": []any{map[string]any{"expr": "up{job=\"$other\"}"}}}, want: ""},
+		{name: "dollar", panel: map[string]any{"targets": []any{map[string]any{"expr": "up{job=\"$job\"}"}}}, want: "job"},
+		{name: "braces with format", panel: map[string]any{"targets": []any{map[string]any{"expr": "up{job=~\"${job:regex}\"}"}}}, want: "job"},
+		{name: "brackets", panel: map[string]any{"targets": []any{map[string]any{"expr": "up{job=\"[[job]]\"}"}}}, want: "job"},
+		{name: "nested", panel: map[string]any{"targets": []any{map[string]any{"filters": []any{map[string]any{"value": "$job"}}}}}, want: "job"},
+		{name: "hidden query", panel: map[string]any{"targets": []any{map[string]any{"expr": "$job", "hide": true}}}, want: ""},
+		{name: "panel data source", panel: map[string]any{"datasource": map[string]any{"uid": "${ds}"}}, want: "ds"},
+		{name: "interval", panel: map[string]any{"interval": "$job"}, want: "job"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getRenderPanelVariable(dashboard, simplejson.NewFromAny(tt.panel)))
+		})
+	}
+}
@@ -90,9 +90,18 @@ var (
 	// MRenderingRequestTotal is a metric counter for image rendering requests
 	MRenderingRequestTotal *prometheus.CounterVec
 
+	// MRenderingLabeledRequestTotal is a metric counter for image rendering requests with a metrics label
+	MRenderingLabeledRequestTotal *prometheus.CounterVec
+
 	// MRenderingQueue is a metric gauge for image rendering queue size
 	MRenderingQueue prometheus.Gauge
 
+	// MRenderingLimitReached is a metric counter for renders rejected at the concurrent render limit
+	MRenderingLimitReached prometheus.Counter
+
+	// MRenderQueueDepth is a metric gauge for the number of renders in flight against the concurrent render limit
+	MRenderQueueDepth prometheus.Gauge
+
 	// MAccessEvaluationCount is a metric gauge for total number of evaluation requests
 	MAccessEvaluationCount prometheus.Counter
 
@@ -126,6 +135,9 @@ var (
 	// MRenderingSummary is a metric summary for image rendering request duration
 	MRenderingSummary *prometheus.SummaryVec
 
+	// MRenderingLabeledSummary is a metric summary for image rendering request duration with a metrics label
+	MRenderingLabeledSummary *prometheus.SummaryVec
+
 	// MRenderingUserLookupSummary is a metric summary for image rendering user lookup duration
 	MRenderingUserLookupSummary *prometheus.SummaryVec
 
@@ -410,6 +422,15 @@ func init() {
 		[]string{"status", "type"},
 	)
 
+	MRenderingLabeledRequestTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "rendering_labeled_request_total",
+			Help:      "counter for rendering requests with a metrics label",
+			Namespace: ExporterName,
+		},
+		[]string{"status", "type", "label"},
+	)
+
 	MRenderingSummary = prometheus.NewSummaryVec(
 		prometheus.SummaryOpts{
 			Name:       "rendering_request_duration_milliseconds",
@@ -420,6 +441,16 @@ func init() {
 		[]string{"status", "type"},
 	)
 
+	MRenderingLabeledSummary = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Name:       "rendering_labeled_request_duration_milliseconds",
+			Help:       "summary of rendering request duration with a metrics label",
+			Objectives: objectiveMap,
+			Namespace:  ExporterName,
+		},
+		[]string{"status", "type", "label"},
+	)
+
 	MRenderingUserLookupSummary = prometheus.NewSummaryVec(
 		prometheus.SummaryOpts{
 			Name:       "rendering_user_lookup_duration_milliseconds",
@@ -436,6 +467,18 @@ func init() {
 		Namespace: ExporterName,
 	})
 
+	MRenderingLimitReached = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:      "rendering_limit_reached_total",
+		Help:      "number of renders rejected at the concurrent render limit",
+		Namespace: ExporterName,
+	})
+
+	MRenderQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:      "render_queue_depth",
+		Help:      "number of renders in flight against the concurrent render limit",
+		Namespace: ExporterName,
+	})
+
 	MDataSourceProxyReqTimer = prometheus.NewSummary(prometheus.SummaryOpts{
 		Name:       "api_dataproxy_request_all_milliseconds",
 		Help:       "summary for dataproxy request duration",
@@ -739,9 +782,13 @@ func initMetricVars(reg prometheus.Registerer) {
 		MDBDataSourceQueryByID,
 		LDAPUsersSyncExecutionTime,
 		MRenderingRequestTotal,
+		MRenderingLabeledRequestTotal,
 		MRenderingSummary,
+		MRenderingLabeledSummary,
 		MRenderingUserLookupSummary,
 		MRenderingQueue,
+		MRenderingLimitReached,
+		MRenderQueueDepth,
 		MAccessPermissionsSummary,
 		MAccessEvaluationsSummary,
 		MAccessSearchPermissionsSummary,
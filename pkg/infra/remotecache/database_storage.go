@@ -122,6 +122,35 @@ func (dc *databaseCache) Delete(ctx context.Context, key string) error {
 	})
 }
 
+func (dc *databaseCache) GetAndDelete(ctx context.Context, key string) ([]byte, error) {
+	data, err := dc.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// of concurrent callers, only the one whose delete removes the item gets it
+	var deleted int64
+	err = dc.SQLStore.WithDbSession(ctx, func(session *db.Session) error {
+		sql := "DELETE FROM cache_data WHERE cache_key=?"
+		res, err := session.Exec(sql, key)
+		if err != nil {
+			return err
+		}
+
+		deleted, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if deleted == 0 {
+		return nil, ErrCacheItemNotFound
+	}
+
+	return data, nil
+}
+
 func (dc *databaseCache) Count(ctx context.Context, prefix string) (int64, error) {
 	res := int64(0)
 	err := dc.SQLStore.WithDbSession(ctx, func(session *db.Session) error {
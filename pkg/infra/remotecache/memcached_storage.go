@@ -57,6 +57,24 @@ func (s *memcachedStorage) Get(ctx context.Context, key string) ([]byte, error)
 	return memcachedItem.Value, nil
 }
 
+// GetAndDelete returns the cached value as an byte array and deletes it.
+// Of concurrent callers, only the one whose delete removes the item gets it.
+func (s *memcachedStorage) GetAndDelete(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.c.Delete(key); err != nil {
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			return nil, ErrCacheItemNotFound
+		}
+		return nil, err
+	}
+
+	return data, nil
+}
+
 func (s *memcachedStorage) Count(ctx context.Context, prefix string) (int64, error) {
 	return 0, ErrNotImplemented
 }
@@ -111,6 +111,25 @@ func (s *redisStorage) Delete(ctx context.Context, key string) error {
 	return cmd.Err()
 }
 
+// GetAndDelete returns the value as byte array and deletes the key in a
+// single transaction.
+func (s *redisStorage) GetAndDelete(ctx context.Context, key string) ([]byte, error) {
+	var get *redis.StringCmd
+	_, err := s.c.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pipe.Del(ctx, key)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrCacheItemNotFound
+		}
+		return nil, err
+	}
+
+	return get.Bytes()
+}
+
 func (s *redisStorage) Count(ctx context.Context, prefix string) (int64, error) {
 	cmd := s.c.Keys(ctx, prefix+"*")
 	if cmd.Err() != nil {
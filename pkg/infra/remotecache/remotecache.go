@@ -70,6 +70,11 @@ type CacheStorage interface {
 	// Delete object from cache
 	Delete(ctx context.Context, key string) error
 
+	// GetAndDelete gets the cache value as an byte array and deletes it. Of
+	// concurrent calls for the same key, only one gets the value, the others
+	// get ErrCacheItemNotFound.
+	GetAndDelete(ctx context.Context, key string) ([]byte, error)
+
 	// Count returns the number of items in the cache.
 	// Optionaly a prefix can be provided to only count items with that prefix
 	// DO NOT USE. Not available for memcached.
@@ -102,6 +107,11 @@ func (ds *RemoteCache) Delete(ctx context.Context, key string) error {
 	return ds.client.Delete(ctx, key)
 }
 
+// GetAndDelete returns the cached value as an byte array and deletes it
+func (ds *RemoteCache) GetAndDelete(ctx context.Context, key string) ([]byte, error) {
+	return ds.client.GetAndDelete(ctx, key)
+}
+
 // Count returns the number of items in the cache.
 func (ds *RemoteCache) Count(ctx context.Context, prefix string) (int64, error) {
 	return ds.client.Count(ctx, prefix)
@@ -173,6 +183,15 @@ func (pcs *encryptedCacheStorage) Delete(ctx context.Context, key string) error
 	return pcs.cache.Delete(ctx, key)
 }
 
+func (pcs *encryptedCacheStorage) GetAndDelete(ctx context.Context, key string) ([]byte, error) {
+	data, err := pcs.cache.GetAndDelete(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pcs.secretsService.Decrypt(ctx, data)
+}
+
 func (pcs *encryptedCacheStorage) Count(ctx context.Context, prefix string) (int64, error) {
 	return pcs.cache.Count(ctx, prefix)
 }
@@ -192,6 +211,10 @@ func (pcs *prefixCacheStorage) Delete(ctx context.Context, key string) error {
 	return pcs.cache.Delete(ctx, pcs.prefix+key)
 }
 
+func (pcs *prefixCacheStorage) GetAndDelete(ctx context.Context, key string) ([]byte, error) {
+	return pcs.cache.GetAndDelete(ctx, pcs.prefix+key)
+}
+
 func (pcs *prefixCacheStorage) Count(ctx context.Context, prefix string) (int64, error) {
 	return pcs.cache.Count(ctx, pcs.prefix+prefix)
 }
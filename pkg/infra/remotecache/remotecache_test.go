@@ -2,6 +2,8 @@ package remotecache
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -52,6 +54,7 @@ func TestInvalidCacheTypeReturnsError(t *testing.T) {
 
 func runTestsForClient(t *testing.T, client CacheStorage) {
 	canPutGetAndDeleteCachedObjects(t, client)
+	canGetAndDeleteCachedObjectsOnce(t, client)
 	canNotFetchExpiredItems(t, client)
 }
 
@@ -105,6 +108,32 @@ func canPutGetAndDeleteCachedObjects(t *testing.T, client CacheStorage) {
 	assert.Error(t, err)
 }
 
+func canGetAndDeleteCachedObjectsOnce(t *testing.T, client CacheStorage) {
+	err := client.Set(context.Background(), "key1", []byte("some bytes"), 0)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var got atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := client.GetAndDelete(context.Background(), "key1")
+			if err == nil {
+				assert.Equal(t, "some bytes", string(data))
+				got.Add(1)
+				return
+			}
+			assert.ErrorIs(t, err, ErrCacheItemNotFound)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), got.Load())
+	_, err = client.Get(context.Background(), "key1")
+	assert.Error(t, err)
+}
+
 func canNotFetchExpiredItems(t *testing.T, client CacheStorage) {
 	dataToCache := []byte("some bytes")
 
@@ -28,6 +28,16 @@ func (fcs FakeCacheStorage) Delete(_ context.Context, key string) error {
 	return nil
 }
 
+func (fcs FakeCacheStorage) GetAndDelete(_ context.Context, key string) ([]byte, error) {
+	value, exist := fcs.Storage[key]
+	if !exist {
+		return nil, ErrCacheItemNotFound
+	}
+
+	delete(fcs.Storage, key)
+	return value, nil
+}
+
 func (fcs FakeCacheStorage) Count(_ context.Context, prefix string) (int64, error) {
 	return int64(len(fcs.Storage)), nil
 }
@@ -103,6 +103,20 @@ func testIntegrationApiKeyDataAccess(t *testing.T, fn getStore) {
 			key, err := ss.GetApiKeyByName(context.Background(), &query)
 			assert.Nil(t, err)
 			assert.Nil(t, key.Expires)
+			assert.Nil(t, key.CreatedBy)
+		})
+
+		t.Run("Add a key with its creator", func(t *testing.T) {
+			createdBy := "user:1"
+			cmd := apikey.AddCommand{OrgID: 1, Name: "with-creator", Key: "asd-creator", CreatedBy: &createdBy}
+			_, err := ss.AddAPIKey(context.Background(), &cmd)
+			assert.Nil(t, err)
+
+			query := apikey.GetByNameQuery{KeyName: "with-creator", OrgID: 1}
+			key, err := ss.GetApiKeyByName(context.Background(), &query)
+			assert.Nil(t, err)
+			require.NotNil(t, key.CreatedBy)
+			assert.Equal(t, "user:1", *key.CreatedBy)
 		})
 
 		t.Run("Add an expiring key", func(t *testing.T) {
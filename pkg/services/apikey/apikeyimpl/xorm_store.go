@@ -125,6 +125,7 @@ func (ss *sqlStore) AddAPIKey(ctx context.Context, cmd *apikey.AddCommand) (res
 			Expires:          expires,
 			ServiceAccountId: cmd.ServiceAccountID,
 			IsRevoked:        &isRevoked,
+			CreatedBy:        cmd.CreatedBy,
 		}
 
 		if _, err := sess.Insert(&t); err != nil {
@@ -28,6 +28,9 @@ type APIKey struct {
 	Expires          *int64       `db:"expires"`
 	ServiceAccountId *int64       `db:"service_account_id"`
 	IsRevoked        *bool        `xorm:"is_revoked" db:"is_revoked"`
+	// CreatedBy is the namespaced ID of the identity that created the key.
+	// It's nil for keys created before it was recorded.
+	CreatedBy *string `xorm:"created_by" db:"created_by"`
 }
 
 func (k APIKey) TableName() string { return "api_key" }
@@ -40,6 +43,9 @@ type AddCommand struct {
 	Key              string       `json:"-"`
 	SecondsToLive    int64        `json:"secondsToLive"`
 	ServiceAccountID *int64       `json:"-"`
+	CreatedBy        *string      `json:"-"`
+	// ValidateOnly only validates the command without creating the key.
+	ValidateOnly bool `json:"validateOnly"`
 }
 
 type DeleteCommand struct {
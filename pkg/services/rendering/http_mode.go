@@ -48,7 +48,7 @@ func (rs *RenderingService) renderViaHTTP(ctx context.Context, renderType Render
 		return nil, err
 	}
 
-	return &RenderResult{FilePath: result.FilePath}, nil
+	return &RenderResult{FilePath: result.FilePath, URL: imageRendererURL.Query().Get("url")}, nil
 }
 
 // renderViaHTTP renders CSV via HTTP
@@ -70,6 +70,9 @@ func (rs *RenderingService) renderCSVViaHTTP(ctx context.Context, renderKey stri
 
 func (rs *RenderingService) generateImageRendererURL(renderType RenderType, opts Opts, renderKey string) (*url.URL, error) {
 	rendererUrl := rs.Cfg.RendererUrl
+	if opts.ServerURL != "" {
+		rendererUrl = opts.ServerURL
+	}
 	if renderType == RenderCSV {
 		rendererUrl += "/csv"
 	}
@@ -80,7 +83,7 @@ func (rs *RenderingService) generateImageRendererURL(renderType RenderType, opts
 	}
 
 	queryParams := imageRendererURL.Query()
-	url := rs.getGrafanaCallbackURL(opts.Path)
+	url := rs.getGrafanaCallbackURL(getRenderPath(opts))
 	queryParams.Add("url", url)
 	queryParams.Add("renderKey", renderKey)
 	queryParams.Add("domain", rs.domain)
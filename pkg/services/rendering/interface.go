@@ -23,6 +23,16 @@ const (
 	RenderPDF RenderType = "pdf"
 )
 
+// RenderPhase describes how far a render request has progressed.
+type RenderPhase string
+
+const (
+	// RenderPhaseQueued is reported once a render request has been accepted.
+	RenderPhaseQueued RenderPhase = "queued"
+	// RenderPhaseRendering is reported when the request is handed to the image renderer.
+	RenderPhaseRendering RenderPhase = "rendering"
+)
+
 type TimeoutOpts struct {
 	Timeout                  time.Duration // Timeout param passed to image-renderer service
 	RequestTimeoutMultiplier time.Duration // RequestTimeoutMultiplier used for plugin/HTTP request context timeout
@@ -49,6 +59,10 @@ type CommonOpts struct {
 	Timezone        string
 	ConcurrentLimit int
 	Headers         map[string][]string
+	// MetricsLabel is the label label of the labeled rendering metrics, which
+	// renders without one aren't counted in. Callers must keep its values
+	// bounded.
+	MetricsLabel string
 }
 
 type CSVOpts struct {
@@ -62,6 +76,40 @@ type Opts struct {
 	Height            int
 	DeviceScaleFactor float64
 	Theme             models.Theme
+	// MaxDataPoints caps the number of data points panels request while
+	// rendering. Zero keeps the dashboard's own setting.
+	MaxDataPoints int
+	// Interval overrides the min interval of the rendered panels' queries,
+	// such as 5m. Like the panels' own min interval, it can make the query
+	// interval coarser than the one computed from the time range and the max
+	// data points, but not finer: a finer interval also needs MaxDataPoints
+	// of at least the time range divided by the interval. Empty keeps the
+	// panels' own min interval.
+	Interval string
+	// Legend overrides the legend of the rendered panels: hidden, bottom or
+	// right. Empty keeps the panels' own setting.
+	Legend string
+	// BackgroundColor overrides the background of the rendered page and
+	// panels. Empty keeps the theme's background.
+	BackgroundColor string
+	// FieldOverrides is a JSON list of field config overrides applied to the
+	// rendered panels for this render only. Empty keeps the panels' own
+	// field config.
+	FieldOverrides string
+	// ServerURL overrides the URL of the remote HTTP image renderer service
+	// for this render. It has no effect when rendering via plugin.
+	ServerURL string
+	// DisableAnimations turns off panel animations and transitions, so that
+	// panels aren't captured while they are still being drawn.
+	DisableAnimations bool
+	// OnProgress, if set, is called as the render moves through its phases.
+	OnProgress func(phase RenderPhase)
+}
+
+func (o Opts) reportProgress(phase RenderPhase) {
+	if o.OnProgress != nil {
+		o.OnProgress(phase)
+	}
 }
 
 type ErrorOpts struct {
@@ -89,6 +137,10 @@ type Result struct {
 
 type RenderResult struct {
 	FilePath string
+	// URL is the Grafana URL the image renderer loaded.
+	URL string
+	// NoData is set if the result is the placeholder for renders without data.
+	NoData bool
 }
 
 type RenderCSVResult struct {
@@ -127,9 +179,24 @@ type Service interface {
 	Render(ctx context.Context, renderType RenderType, opts Opts, session Session) (*RenderResult, error)
 	RenderCSV(ctx context.Context, opts CSVOpts, session Session) (*RenderCSVResult, error)
 	RenderErrorImage(theme models.Theme, error error) (*RenderResult, error)
+	RenderNoDataImage(theme models.Theme) (*RenderResult, error)
 	GetRenderUser(ctx context.Context, key string) (*RenderUser, bool)
 	HasCapability(ctx context.Context, capability CapabilityName) (CapabilitySupportRequestResult, error)
 	IsCapabilitySupported(ctx context.Context, capability CapabilityName) error
 	CreateRenderingSession(ctx context.Context, authOpts AuthOpts, sessionOpts SessionOpts) (Session, error)
 	SanitizeSVG(ctx context.Context, req *SanitizeSVGRequest) (*SanitizeSVGResponse, error)
+	LoadStats() LoadStats
+}
+
+// LoadStats is a snapshot of the render load of the Grafana instance. Renders
+// aren't queued: renders over the concurrent render limit are rejected, so
+// the backpressure is the number of renders in flight against the limit and
+// the number of rejected renders.
+type LoadStats struct {
+	// InFlight is the number of renders being processed by the image renderer.
+	InFlight int `json:"inFlight"`
+	// Limit is the number of concurrent renders over which renders are rejected.
+	Limit int `json:"limit"`
+	// Rejected is the number of renders rejected at the limit since Grafana started.
+	Rejected int64 `json:"rejected"`
 }
@@ -108,6 +108,20 @@ func (mr *MockServiceMockRecorder) IsAvailable(ctx interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAvailable", reflect.TypeOf((*MockService)(nil).IsAvailable), ctx)
 }
 
+// LoadStats mocks base method.
+func (m *MockService) LoadStats() LoadStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadStats")
+	ret0, _ := ret[0].(LoadStats)
+	return ret0
+}
+
+// LoadStats indicates an expected call of LoadStats.
+func (mr *MockServiceMockRecorder) LoadStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadStats", reflect.TypeOf((*MockService)(nil).LoadStats))
+}
+
 // Render mocks base method.
 func (m *MockService) Render(ctx context.Context, renderType RenderType, opts Opts, session Session) (*RenderResult, error) {
 	m.ctrl.T.Helper()
@@ -138,6 +152,21 @@ func (mr *MockServiceMockRecorder) RenderCSV(ctx, opts, session interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenderCSV", reflect.TypeOf((*MockService)(nil).RenderCSV), ctx, opts, session)
 }
 
+// RenderNoDataImage mocks base method.
+func (m *MockService) RenderNoDataImage(theme models.Theme) (*RenderResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenderNoDataImage", theme)
+	ret0, _ := ret[0].(*RenderResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenderNoDataImage indicates an expected call of RenderNoDataImage.
+func (mr *MockServiceMockRecorder) RenderNoDataImage(theme interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenderNoDataImage", reflect.TypeOf((*MockService)(nil).RenderNoDataImage), theme)
+}
+
 // RenderErrorImage mocks base method.
 func (m *MockService) RenderErrorImage(theme models.Theme, err error) (*RenderResult, error) {
 	m.ctrl.T.Helper()
@@ -27,7 +27,7 @@ func (rs *RenderingService) renderViaPlugin(ctx context.Context, renderType Rend
 	}
 
 	req := &pluginextensionv2.RenderRequest{
-		Url:               rs.getGrafanaCallbackURL(opts.Path),
+		Url:               rs.getGrafanaCallbackURL(getRenderPath(opts)),
 		Width:             int32(opts.Width),
 		Height:            int32(opts.Height),
 		DeviceScaleFactor: float32(opts.DeviceScaleFactor),
@@ -58,7 +58,7 @@ func (rs *RenderingService) renderViaPlugin(ctx context.Context, renderType Rend
 		return nil, fmt.Errorf("rendering failed: %s", rsp.Error)
 	}
 
-	return &RenderResult{FilePath: filePath}, err
+	return &RenderResult{FilePath: filePath, URL: req.Url}, err
 }
 
 func (rs *RenderingService) renderCSVViaPlugin(ctx context.Context, renderKey string, opts CSVOpts) (*RenderCSVResult, error) {
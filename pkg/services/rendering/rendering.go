@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -35,6 +36,7 @@ type RenderingService struct {
 	sanitizeURL       string
 	domain            string
 	inProgressCount   int32
+	rejectedCount     int64
 	version           string
 	versionMutex      sync.RWMutex
 	capabilities      []Capability
@@ -246,6 +248,24 @@ func (rs *RenderingService) RenderErrorImage(theme models.Theme, err error) (*Re
 	}, nil
 }
 
+// RenderNoDataImage returns the placeholder image for renders whose panels
+// have no data, so that they can be told apart from failed renders.
+func (rs *RenderingService) RenderNoDataImage(theme models.Theme) (*RenderResult, error) {
+	if theme == "" {
+		theme = models.ThemeDark
+	}
+
+	imgPath := filepath.Join(rs.Cfg.HomePath, fmt.Sprintf("public/img/rendering_no_data_%s.png", theme))
+	if _, err := os.Stat(imgPath); err != nil {
+		return nil, err
+	}
+
+	return &RenderResult{
+		FilePath: imgPath,
+		NoData:   true,
+	}, nil
+}
+
 func (rs *RenderingService) renderUnavailableImage() *RenderResult {
 	imgPath := "public/img/rendering_plugin_not_installed.png"
 
@@ -265,7 +285,7 @@ func (rs *RenderingService) Render(ctx context.Context, renderType RenderType, o
 	result, err := rs.render(ctx, renderType, opts, renderKeyProvider)
 
 	elapsedTime := time.Since(startTime).Milliseconds()
-	saveMetrics(elapsedTime, err, renderType)
+	saveMetrics(elapsedTime, err, renderType, opts.MetricsLabel)
 
 	return result, err
 }
@@ -273,6 +293,7 @@ func (rs *RenderingService) Render(ctx context.Context, renderType RenderType, o
 func (rs *RenderingService) render(ctx context.Context, renderType RenderType, opts Opts, renderKeyProvider renderKeyProvider) (*RenderResult, error) {
 	if int(atomic.LoadInt32(&rs.inProgressCount)) > opts.ConcurrentLimit {
 		rs.log.Warn("Could not render image, hit the currency limit", "concurrencyLimit", opts.ConcurrentLimit, "path", opts.Path)
+		rs.rejectRender()
 		if opts.ErrorConcurrentLimitReached {
 			return nil, ErrConcurrentLimitReached
 		}
@@ -307,6 +328,8 @@ func (rs *RenderingService) render(ctx context.Context, renderType RenderType, o
 		}
 	}
 
+	opts.reportProgress(RenderPhaseQueued)
+
 	rs.log.Info("Rendering", "path", opts.Path, "userID", opts.AuthOpts.UserID)
 	if math.IsInf(opts.DeviceScaleFactor, 0) || math.IsNaN(opts.DeviceScaleFactor) || opts.DeviceScaleFactor == 0 {
 		opts.DeviceScaleFactor = 1
@@ -316,16 +339,45 @@ func (rs *RenderingService) render(ctx context.Context, renderType RenderType, o
 		return nil, err
 	}
 
-	defer renderKeyProvider.afterRequest(ctx, opts.AuthOpts, renderKey)
+	// the render key is cleaned up even if the render was canceled
+	defer renderKeyProvider.afterRequest(context.WithoutCancel(ctx), opts.AuthOpts, renderKey)
+
+	// don't take a slot for a render whose request was already canceled
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	defer func() {
-		metrics.MRenderingQueue.Set(float64(atomic.AddInt32(&rs.inProgressCount, -1)))
-	}()
+	defer rs.addInProgress(-1)
 
-	metrics.MRenderingQueue.Set(float64(atomic.AddInt32(&rs.inProgressCount, 1)))
+	rs.addInProgress(1)
+	opts.reportProgress(RenderPhaseRendering)
 	return rs.renderAction(ctx, renderType, renderKey, opts)
 }
 
+// LoadStats returns the number of renders in flight against the concurrent
+// render limit, and the number of renders rejected at the limit.
+func (rs *RenderingService) LoadStats() LoadStats {
+	return LoadStats{
+		InFlight: int(atomic.LoadInt32(&rs.inProgressCount)),
+		Limit:    rs.Cfg.RendererConcurrentRequestLimit,
+		Rejected: atomic.LoadInt64(&rs.rejectedCount),
+	}
+}
+
+// addInProgress changes the number of renders in flight by delta, and
+// reports it as the render queue size and depth.
+func (rs *RenderingService) addInProgress(delta int32) {
+	count := float64(atomic.AddInt32(&rs.inProgressCount, delta))
+	metrics.MRenderingQueue.Set(count)
+	metrics.MRenderQueueDepth.Set(count)
+}
+
+// rejectRender counts a render rejected at the concurrent render limit.
+func (rs *RenderingService) rejectRender() {
+	atomic.AddInt64(&rs.rejectedCount, 1)
+	metrics.MRenderingLimitReached.Inc()
+}
+
 func (rs *RenderingService) RenderCSV(ctx context.Context, opts CSVOpts, session Session) (*RenderCSVResult, error) {
 	startTime := time.Now()
 
@@ -336,7 +388,7 @@ func (rs *RenderingService) RenderCSV(ctx context.Context, opts CSVOpts, session
 	result, err := rs.renderCSV(ctx, opts, renderKeyProvider)
 
 	elapsedTime := time.Since(startTime).Milliseconds()
-	saveMetrics(elapsedTime, err, RenderCSV)
+	saveMetrics(elapsedTime, err, RenderCSV, opts.MetricsLabel)
 
 	return result, err
 }
@@ -361,6 +413,7 @@ func (rs *RenderingService) SanitizeSVG(ctx context.Context, req *SanitizeSVGReq
 
 func (rs *RenderingService) renderCSV(ctx context.Context, opts CSVOpts, renderKeyProvider renderKeyProvider) (*RenderCSVResult, error) {
 	if int(atomic.LoadInt32(&rs.inProgressCount)) > opts.ConcurrentLimit {
+		rs.rejectRender()
 		return nil, ErrConcurrentLimitReached
 	}
 
@@ -376,11 +429,9 @@ func (rs *RenderingService) renderCSV(ctx context.Context, opts CSVOpts, renderK
 
 	defer renderKeyProvider.afterRequest(ctx, opts.AuthOpts, renderKey)
 
-	defer func() {
-		metrics.MRenderingQueue.Set(float64(atomic.AddInt32(&rs.inProgressCount, -1)))
-	}()
+	defer rs.addInProgress(-1)
 
-	metrics.MRenderingQueue.Set(float64(atomic.AddInt32(&rs.inProgressCount, 1)))
+	rs.addInProgress(1)
 	return rs.renderCSVAction(ctx, renderKey, opts)
 }
 
@@ -437,6 +488,58 @@ func (rs *RenderingService) getGrafanaCallbackURL(path string) string {
 	return fmt.Sprintf("%s://%s:%s%s/%s&render=1", protocol, rs.domain, rs.Cfg.HTTPPort, subPath, path)
 }
 
+// getRenderPath returns the path of the Grafana resource to render with the
+// render state from opts appended as query parameters, unless the path
+// already sets them.
+func getRenderPath(opts Opts) string {
+	params := url.Values{}
+	if opts.MaxDataPoints > 0 {
+		params.Set("maxDataPoints", strconv.Itoa(opts.MaxDataPoints))
+	}
+	if opts.Interval != "" {
+		params.Set("interval", opts.Interval)
+	}
+	if opts.Legend != "" {
+		params.Set("legend", opts.Legend)
+	}
+	if opts.BackgroundColor != "" {
+		params.Set("bgColor", opts.BackgroundColor)
+	}
+	if opts.FieldOverrides != "" {
+		params.Set("fieldOverrides", opts.FieldOverrides)
+	}
+	if opts.DisableAnimations {
+		params.Set("disableAnimations", "true")
+	}
+
+	return appendMissingQueryParams(opts.Path, params)
+}
+
+func appendMissingQueryParams(path string, params url.Values) string {
+	existing := url.Values{}
+	if i := strings.Index(path, "?"); i >= 0 {
+		// an unparsable query only means we can't tell what the path already sets
+		existing, _ = url.ParseQuery(path[i+1:])
+	}
+
+	for key := range params {
+		if existing.Has(key) {
+			params.Del(key)
+		}
+	}
+
+	if len(params) == 0 {
+		return path
+	}
+
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	return path + separator + params.Encode()
+}
+
 func isoTimeOffsetToPosixTz(isoOffset string) string {
 	// invert offset
 	if strings.HasPrefix(isoOffset, "UTC+") {
@@ -448,18 +551,18 @@ func isoTimeOffsetToPosixTz(isoOffset string) string {
 	return isoOffset
 }
 
-func saveMetrics(elapsedTime int64, err error, renderType RenderType) {
-	if err == nil {
-		metrics.MRenderingRequestTotal.WithLabelValues("success", string(renderType)).Inc()
-		metrics.MRenderingSummary.WithLabelValues("success", string(renderType)).Observe(float64(elapsedTime))
-		return
+func saveMetrics(elapsedTime int64, err error, renderType RenderType, label string) {
+	status := "success"
+	if errors.Is(err, ErrTimeout) {
+		status = "timeout"
+	} else if err != nil {
+		status = "failure"
 	}
 
-	if errors.Is(err, ErrTimeout) {
-		metrics.MRenderingRequestTotal.WithLabelValues("timeout", string(renderType)).Inc()
-		metrics.MRenderingSummary.WithLabelValues("timeout", string(renderType)).Observe(float64(elapsedTime))
-	} else {
-		metrics.MRenderingRequestTotal.WithLabelValues("failure", string(renderType)).Inc()
-		metrics.MRenderingSummary.WithLabelValues("failure", string(renderType)).Observe(float64(elapsedTime))
+	metrics.MRenderingRequestTotal.WithLabelValues(status, string(renderType)).Inc()
+	metrics.MRenderingSummary.WithLabelValues(status, string(renderType)).Observe(float64(elapsedTime))
+	if label != "" {
+		metrics.MRenderingLabeledRequestTotal.WithLabelValues(status, string(renderType), label).Inc()
+		metrics.MRenderingLabeledSummary.WithLabelValues(status, string(renderType), label).Observe(float64(elapsedTime))
 	}
 }
@@ -6,13 +6,17 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -69,6 +73,75 @@ func TestGetUrl(t *testing.T) {
 	})
 }
 
+func TestGenerateImageRendererURL(t *testing.T) {
+	rs := &RenderingService{
+		Cfg: &setting.Cfg{RendererUrl: "http://renderer:8081/render", RendererCallbackUrl: "http://grafana/"},
+	}
+
+	t.Run("should use the configured renderer", func(t *testing.T) {
+		u, err := rs.generateImageRendererURL(RenderPNG, Opts{CommonOpts: CommonOpts{Path: "d/uid?orgId=1"}}, "key")
+		require.NoError(t, err)
+		require.Equal(t, "renderer:8081", u.Host)
+	})
+
+	t.Run("should use the renderer of the opts", func(t *testing.T) {
+		opts := Opts{CommonOpts: CommonOpts{Path: "d/uid?orgId=1"}, ServerURL: "http://renderer-heavy:8081/render"}
+		u, err := rs.generateImageRendererURL(RenderPNG, opts, "key")
+		require.NoError(t, err)
+		require.Equal(t, "renderer-heavy:8081", u.Host)
+		require.Equal(t, "/render", u.Path)
+	})
+
+	t.Run("should pass a full page height to the renderer", func(t *testing.T) {
+		u, err := rs.generateImageRendererURL(RenderPNG, Opts{CommonOpts: CommonOpts{Path: "d/uid?orgId=1"}, Width: 800, Height: -1}, "key")
+		require.NoError(t, err)
+		require.Equal(t, "-1", u.Query().Get("height"))
+		require.False(t, u.Query().Has("fullPageImage"))
+	})
+}
+
+func TestGetRenderPath(t *testing.T) {
+	t.Run("should return the path unchanged without render state", func(t *testing.T) {
+		path := getRenderPath(Opts{CommonOpts: CommonOpts{Path: "d-solo/uid/slug?panelId=1"}})
+		require.Equal(t, "d-solo/uid/slug?panelId=1", path)
+	})
+
+	t.Run("should append maxDataPoints", func(t *testing.T) {
+		path := getRenderPath(Opts{CommonOpts: CommonOpts{Path: "d-solo/uid/slug?panelId=1"}, MaxDataPoints: 100})
+		require.Equal(t, "d-solo/uid/slug?panelId=1&maxDataPoints=100", path)
+	})
+
+	t.Run("should append the interval", func(t *testing.T) {
+		path := getRenderPath(Opts{CommonOpts: CommonOpts{Path: "d-solo/uid/slug?panelId=1"}, Interval: "5m"})
+		require.Equal(t, "d-solo/uid/slug?panelId=1&interval=5m", path)
+	})
+
+	t.Run("should append the legend override", func(t *testing.T) {
+		path := getRenderPath(Opts{CommonOpts: CommonOpts{Path: "d-solo/uid/slug?panelId=1"}, Legend: "hidden"})
+		require.Equal(t, "d-solo/uid/slug?panelId=1&legend=hidden", path)
+	})
+
+	t.Run("should append the background color", func(t *testing.T) {
+		path := getRenderPath(Opts{CommonOpts: CommonOpts{Path: "d/uid/slug"}, BackgroundColor: "#ffffff"})
+		require.Equal(t, "d/uid/slug?bgColor=%23ffffff", path)
+	})
+
+	t.Run("should append the field overrides", func(t *testing.T) {
+		path := getRenderPath(Opts{CommonOpts: CommonOpts{Path: "d/uid/slug"}, FieldOverrides: `[{"matcher":{"id":"byName"}}]`})
+		require.Equal(t, "d/uid/slug?fieldOverrides=%5B%7B%22matcher%22%3A%7B%22id%22%3A%22byName%22%7D%7D%5D", path)
+	})
+
+	t.Run("should append disableAnimations", func(t *testing.T) {
+		path := getRenderPath(Opts{CommonOpts: CommonOpts{Path: "d/uid/slug"}, DisableAnimations: true})
+		require.Equal(t, "d/uid/slug?disableAnimations=true", path)
+	})
+
+	t.Run("should not override maxDataPoints already set by the path", func(t *testing.T) {
+		path := getRenderPath(Opts{CommonOpts: CommonOpts{Path: "d-solo/uid/slug?maxDataPoints=50"}, MaxDataPoints: 100})
+		require.Equal(t, "d-solo/uid/slug?maxDataPoints=50", path)
+	})
+}
+
 func TestRenderErrorImage(t *testing.T) {
 	path, err := filepath.Abs("../../../")
 	require.NoError(t, err)
@@ -175,6 +248,175 @@ func TestRenderLimitImageError(t *testing.T) {
 	assert.Nil(t, result)
 }
 
+func TestRenderProgress(t *testing.T) {
+	rs := RenderingService{
+		Cfg:                         &setting.Cfg{RendererUrl: "http://localhost:8081/render"},
+		log:                         log.New("test"),
+		perRequestRenderKeyProvider: &jwtRenderKeyProvider{authToken: []byte("token"), keyExpiry: time.Minute},
+	}
+
+	var phases []RenderPhase
+	rs.renderAction = func(ctx context.Context, renderType RenderType, renderKey string, opts Opts) (*RenderResult, error) {
+		require.Equal(t, []RenderPhase{RenderPhaseQueued, RenderPhaseRendering}, phases)
+		return &RenderResult{FilePath: "render.png"}, nil
+	}
+
+	opts := Opts{
+		CommonOpts: CommonOpts{ConcurrentLimit: 1},
+		OnProgress: func(phase RenderPhase) {
+			phases = append(phases, phase)
+		},
+	}
+	result, err := rs.Render(context.Background(), RenderPNG, opts, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "render.png", result.FilePath)
+	assert.Equal(t, []RenderPhase{RenderPhaseQueued, RenderPhaseRendering}, phases)
+}
+
+func TestRenderCanceled(t *testing.T) {
+	rs := RenderingService{
+		Cfg:                         &setting.Cfg{RendererUrl: "http://localhost:8081/render"},
+		log:                         log.New("test"),
+		perRequestRenderKeyProvider: &jwtRenderKeyProvider{authToken: []byte("token"), keyExpiry: time.Minute},
+	}
+	opts := Opts{CommonOpts: CommonOpts{ConcurrentLimit: 1}}
+
+	t.Run("should cancel the render and release its slot when the request is canceled", func(t *testing.T) {
+		rendering := make(chan struct{})
+		rs.renderAction = func(ctx context.Context, renderType RenderType, renderKey string, opts Opts) (*RenderResult, error) {
+			close(rendering)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-rendering
+			assert.Equal(t, int32(1), atomic.LoadInt32(&rs.inProgressCount))
+			cancel()
+		}()
+
+		result, err := rs.Render(ctx, RenderPNG, opts, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, result)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&rs.inProgressCount))
+	})
+
+	t.Run("should not start a render for a canceled request", func(t *testing.T) {
+		rs.renderAction = func(ctx context.Context, renderType RenderType, renderKey string, opts Opts) (*RenderResult, error) {
+			t.Fatal("render must not start")
+			return nil, nil
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		result, err := rs.Render(ctx, RenderPNG, opts, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, result)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&rs.inProgressCount))
+	})
+}
+
+func TestRenderNoDataImage(t *testing.T) {
+	path, err := filepath.Abs("../../../")
+	require.NoError(t, err)
+
+	rs := RenderingService{
+		Cfg: &setting.Cfg{
+			HomePath: path,
+		},
+	}
+
+	t.Run("No theme set returns no data image with dark theme", func(t *testing.T) {
+		result, err := rs.RenderNoDataImage("")
+		require.NoError(t, err)
+		assert.Equal(t, path+"/public/img/rendering_no_data_dark.png", result.FilePath)
+		assert.True(t, result.NoData)
+	})
+
+	t.Run("Light theme returns no data image with light theme", func(t *testing.T) {
+		result, err := rs.RenderNoDataImage(models.ThemeLight)
+		require.NoError(t, err)
+		assert.Equal(t, path+"/public/img/rendering_no_data_light.png", result.FilePath)
+	})
+
+	t.Run("Unknown image path returns error", func(t *testing.T) {
+		result, err := rs.RenderNoDataImage("abc")
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+}
+
+func TestRenderLoadStats(t *testing.T) {
+	const limit = 3
+	rs := RenderingService{
+		Cfg:                         &setting.Cfg{RendererUrl: "http://localhost:8081/render", RendererConcurrentRequestLimit: limit},
+		log:                         log.New("test"),
+		perRequestRenderKeyProvider: &jwtRenderKeyProvider{authToken: []byte("token"), keyExpiry: time.Minute},
+	}
+	opts := Opts{CommonOpts: CommonOpts{ConcurrentLimit: limit}, ErrorOpts: ErrorOpts{ErrorConcurrentLimitReached: true}}
+
+	started := make(chan struct{}, limit+1)
+	release := make(chan struct{})
+	rs.renderAction = func(ctx context.Context, renderType RenderType, renderKey string, opts Opts) (*RenderResult, error) {
+		started <- struct{}{}
+		<-release
+		return &RenderResult{}, nil
+	}
+
+	// renders are only rejected once more renders than the limit are in flight
+	var wg sync.WaitGroup
+	for i := 0; i < limit+1; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := rs.Render(context.Background(), RenderPNG, opts, nil)
+			assert.NoError(t, err)
+		}()
+	}
+	for i := 0; i < limit+1; i++ {
+		<-started
+	}
+	assert.Equal(t, LoadStats{InFlight: limit + 1, Limit: limit}, rs.LoadStats())
+	assert.Equal(t, float64(limit+1), testutil.ToFloat64(metrics.MRenderQueueDepth))
+
+	var rejected sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		rejected.Add(1)
+		go func() {
+			defer rejected.Done()
+			_, err := rs.Render(context.Background(), RenderPNG, opts, nil)
+			assert.ErrorIs(t, err, ErrConcurrentLimitReached)
+		}()
+	}
+	rejected.Wait()
+	_, err := rs.RenderCSV(context.Background(), CSVOpts{CommonOpts: opts.CommonOpts}, nil)
+	assert.ErrorIs(t, err, ErrConcurrentLimitReached)
+	assert.Equal(t, LoadStats{InFlight: limit + 1, Limit: limit, Rejected: 6}, rs.LoadStats())
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, LoadStats{Limit: limit, Rejected: 6}, rs.LoadStats())
+	assert.Zero(t, testutil.ToFloat64(metrics.MRenderQueueDepth))
+}
+
+func TestSaveMetrics(t *testing.T) {
+	total := metrics.MRenderingRequestTotal.WithLabelValues("success", string(RenderPNG))
+	labeled := metrics.MRenderingLabeledRequestTotal.WithLabelValues("timeout", string(RenderPNG), "report")
+	totalBefore, labeledBefore := testutil.ToFloat64(total), testutil.ToFloat64(labeled)
+	labeledSeries := testutil.CollectAndCount(metrics.MRenderingLabeledRequestTotal)
+
+	// renders without a label are only counted in the unlabeled metrics
+	saveMetrics(10, nil, RenderPNG, "")
+	assert.Equal(t, totalBefore+1, testutil.ToFloat64(total))
+	assert.Equal(t, labeledSeries, testutil.CollectAndCount(metrics.MRenderingLabeledRequestTotal))
+
+	saveMetrics(10, ErrTimeout, RenderPNG, "report")
+	assert.Equal(t, totalBefore+1, testutil.ToFloat64(total))
+	assert.Equal(t, labeledBefore+1, testutil.ToFloat64(labeled))
+}
+
 func TestRenderingServiceGetRemotePluginVersion(t *testing.T) {
 	cfg := setting.NewCfg()
 	rs := &RenderingService{
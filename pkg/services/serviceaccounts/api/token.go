@@ -7,6 +7,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
 	"github.com/grafana/grafana/pkg/components/satokengen"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
@@ -143,6 +144,8 @@ func (api *ServiceAccountsAPI) CreateToken(c *contextmodel.ReqContext) response.
 
 	// Force affected service account to be the one referenced in the URL
 	cmd.OrgId = c.SignedInUser.GetOrgID()
+	createdBy := identity.NewNamespaceIDString(c.SignedInUser.GetNamespacedID()).String()
+	cmd.CreatedBy = &createdBy
 
 	if api.cfg.ApiKeyMaxSecondsToLive != -1 {
 		if cmd.SecondsToLive == 0 {
@@ -174,9 +177,12 @@ func (api *ServiceAccountsAPI) CreateToken(c *contextmodel.ReqContext) response.
 	}
 
 	result := &dtos.NewApiKeyResult{
-		ID:   apiKey.ID,
-		Name: apiKey.Name,
-		Key:  newKeyInfo.ClientSecret,
+		ID:               apiKey.ID,
+		Name:             apiKey.Name,
+		Key:              newKeyInfo.ClientSecret,
+		CreatedBy:        apiKey.CreatedBy,
+		CreatedAt:        apiKey.Created,
+		ServiceAccountID: apiKey.ServiceAccountId,
 	}
 
 	return response.JSON(http.StatusOK, result)
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/apikey"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
@@ -126,6 +128,57 @@ func TestServiceAccountsAPI_CreateToken(t *testing.T) {
 	}
 }
 
+func TestServiceAccountsAPI_CreateTokenServiceAccountID(t *testing.T) {
+	saID := int64(1)
+	server := setupTests(t, func(a *ServiceAccountsAPI) {
+		a.cfg.ApiKeyMaxSecondsToLive = -1
+		a.service = &satests.FakeServiceAccountService{
+			ExpectedAPIKey: &apikey.APIKey{ID: 2, Name: "test", ServiceAccountId: &saID},
+		}
+	})
+	req := server.NewRequest(http.MethodPost, "/api/serviceaccounts/1/tokens", strings.NewReader(`{"name": "test"}`))
+	webtest.RequestWithSignedInUser(req, &user.SignedInUser{OrgID: 1, Permissions: map[int64]map[string][]string{1: accesscontrol.GroupScopesByAction(
+		[]accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: "serviceaccounts:id:1"}},
+	)}})
+	res, err := server.SendJSON(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var result dtos.NewApiKeyResult
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+	require.NoError(t, res.Body.Close())
+
+	require.NotNil(t, result.ServiceAccountID)
+	assert.Equal(t, saID, *result.ServiceAccountID)
+}
+
+func TestServiceAccountsAPI_CreateTokenCreatedBy(t *testing.T) {
+	createdBy := "user:1"
+	service := &satests.FakeServiceAccountService{
+		ExpectedAPIKey: &apikey.APIKey{ID: 2, Name: "test", CreatedBy: &createdBy},
+	}
+	server := setupTests(t, func(a *ServiceAccountsAPI) {
+		a.cfg.ApiKeyMaxSecondsToLive = -1
+		a.service = service
+	})
+	req := server.NewRequest(http.MethodPost, "/api/serviceaccounts/1/tokens", strings.NewReader(`{"name": "test"}`))
+	webtest.RequestWithSignedInUser(req, &user.SignedInUser{UserID: 1, OrgID: 1, Permissions: map[int64]map[string][]string{1: accesscontrol.GroupScopesByAction(
+		[]accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: "serviceaccounts:id:1"}},
+	)}})
+	res, err := server.SendJSON(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var result dtos.NewApiKeyResult
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+	require.NoError(t, res.Body.Close())
+
+	require.NotNil(t, service.AddServiceAccountTokenCmd.CreatedBy)
+	assert.Equal(t, "user:1", *service.AddServiceAccountTokenCmd.CreatedBy)
+	require.NotNil(t, result.CreatedBy)
+	assert.Equal(t, "user:1", *result.CreatedBy)
+}
+
 func TestServiceAccountsAPI_DeleteToken(t *testing.T) {
 	type TestCase struct {
 		desc         string
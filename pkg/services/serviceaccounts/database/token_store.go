@@ -56,6 +56,7 @@ func (s *ServiceAccountsStoreImpl) AddServiceAccountToken(ctx context.Context, s
 			Key:              cmd.Key,
 			SecondsToLive:    cmd.SecondsToLive,
 			ServiceAccountID: &serviceAccountId,
+			CreatedBy:        cmd.CreatedBy,
 		}
 
 		key, err := s.apiKeyService.AddAPIKey(ctx, addKeyCmd)
@@ -29,11 +29,13 @@ func TestStore_AddServiceAccountToken(t *testing.T) {
 			key, err := apikeygen.New(user.OrgID, keyName)
 			require.NoError(t, err)
 
+			createdBy := "user:1"
 			cmd := serviceaccounts.AddServiceAccountTokenCommand{
 				Name:          keyName,
 				OrgId:         user.OrgID,
 				Key:           key.HashedKey,
 				SecondsToLive: tc.secondsToLive,
+				CreatedBy:     &createdBy,
 			}
 
 			newKey, err := store.AddServiceAccountToken(context.Background(), user.ID, &cmd)
@@ -59,6 +61,8 @@ func TestStore_AddServiceAccountToken(t *testing.T) {
 					found = true
 					require.Equal(t, key.HashedKey, newKey.Key)
 					require.False(t, *k.IsRevoked)
+					require.NotNil(t, k.CreatedBy)
+					require.Equal(t, createdBy, *k.CreatedBy)
 
 					if tc.secondsToLive == 0 {
 						require.Nil(t, k.Expires)
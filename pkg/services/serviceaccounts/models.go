@@ -107,6 +107,8 @@ type AddServiceAccountTokenCommand struct {
 	OrgId         int64  `json:"-"`
 	Key           string `json:"-"`
 	SecondsToLive int64  `json:"secondsToLive"`
+	// CreatedBy is the namespaced ID of the identity creating the token.
+	CreatedBy *string `json:"-"`
 }
 
 type SearchOrgServiceAccountsQuery struct {
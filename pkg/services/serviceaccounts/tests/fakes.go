@@ -17,11 +17,15 @@ type FakeServiceAccountService struct {
 	ExpectedServiceAccountID               int64
 	ExpectedServiceAccountProfile          *serviceaccounts.ServiceAccountProfileDTO
 	ExpectedServiceAccountTokens           []apikey.APIKey
+
+	// AddServiceAccountTokenCmd is the last command passed to AddServiceAccountToken.
+	AddServiceAccountTokenCmd *serviceaccounts.AddServiceAccountTokenCommand
 }
 
 var _ serviceaccounts.Service = new(FakeServiceAccountService)
 
 func (f *FakeServiceAccountService) AddServiceAccountToken(ctx context.Context, id int64, cmd *serviceaccounts.AddServiceAccountTokenCommand) (*apikey.APIKey, error) {
+	f.AddServiceAccountTokenCmd = cmd
 	return f.ExpectedAPIKey, f.ExpectedErr
 }
 
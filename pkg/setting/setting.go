@@ -149,9 +149,14 @@ type Cfg struct {
 	RendererAuthToken              string
 	RendererConcurrentRequestLimit int
 	RendererRenderKeyLifeTime      time.Duration
+	RendererRenderTokenLifetime    time.Duration
+	RendererDebugErrors            bool
+	RendererMetricsLabels          []string
 	RendererDefaultImageWidth      int
 	RendererDefaultImageHeight     int
 	RendererDefaultImageScale      float64
+	RendererTagServerUrls          map[string]string
+	RendererURLTemplates           map[string]string
 
 	// Security
 	DisableInitAdminCreation          bool
@@ -1756,9 +1761,31 @@ func (cfg *Cfg) readRenderingSettings(iniFile *ini.File) error {
 
 	cfg.RendererConcurrentRequestLimit = renderSec.Key("concurrent_render_request_limit").MustInt(30)
 	cfg.RendererRenderKeyLifeTime = renderSec.Key("render_key_lifetime").MustDuration(5 * time.Minute)
+	cfg.RendererRenderTokenLifetime = renderSec.Key("render_token_lifetime").MustDuration(time.Minute)
+	cfg.RendererDebugErrors = renderSec.Key("debug_render_errors").MustBool(false)
+	cfg.RendererMetricsLabels = util.SplitString(renderSec.Key("metrics_labels").String())
 	cfg.RendererDefaultImageWidth = renderSec.Key("default_image_width").MustInt(1000)
 	cfg.RendererDefaultImageHeight = renderSec.Key("default_image_height").MustInt(500)
 	cfg.RendererDefaultImageScale = renderSec.Key("default_image_scale").MustFloat64(1)
+
+	tagServerUrls := iniFile.Section("rendering.tag_server_urls").Keys()
+	cfg.RendererTagServerUrls = make(map[string]string, len(tagServerUrls))
+	for _, key := range tagServerUrls {
+		if _, err := url.Parse(key.Value()); err != nil {
+			return fmt.Errorf("invalid renderer url for tag %q: %w", key.Name(), err)
+		}
+		cfg.RendererTagServerUrls[key.Name()] = key.Value()
+	}
+
+	urlTemplates := iniFile.Section("rendering.url_templates").Keys()
+	cfg.RendererURLTemplates = make(map[string]string, len(urlTemplates))
+	for _, key := range urlTemplates {
+		if _, err := url.Parse(key.Value()); err != nil {
+			return fmt.Errorf("invalid render url template %q: %w", key.Name(), err)
+		}
+		cfg.RendererURLTemplates[key.Name()] = key.Value()
+	}
+
 	cfg.ImagesDir = filepath.Join(cfg.DataPath, "png")
 	cfg.CSVsDir = filepath.Join(cfg.DataPath, "csv")
 	cfg.PDFsDir = filepath.Join(cfg.DataPath, "pdf")
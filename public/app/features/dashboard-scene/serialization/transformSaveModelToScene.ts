@@ -30,6 +30,7 @@ import {
   AdHocFiltersVariable,
 } from '@grafana/scenes';
 import { DashboardModel, PanelModel } from 'app/features/dashboard/state';
+import { applyRenderOverrides } from 'app/features/dashboard/utils/renderOverrides';
 import { DashboardDTO } from 'app/types';
 
 import { AlertStatesDataLayer } from '../scene/AlertStatesDataLayer';
@@ -73,6 +74,7 @@ export interface SaveModelToSceneOptions {
 export function transformSaveModelToScene(rsp: DashboardDTO): DashboardScene {
   // Just to have migrations run
   const oldModel = new DashboardModel(rsp.dashboard, rsp.meta);
+  applyRenderOverrides(oldModel);
 
   const scene = createDashboardSceneFromDashboardModel(oldModel);
   // TODO: refactor createDashboardSceneFromDashboardModel to work on Dashboard schema model
@@ -10,6 +10,7 @@ import { startMeasure, stopMeasure } from 'app/core/utils/metrics';
 import { dashboardLoaderSrv } from 'app/features/dashboard/services/DashboardLoaderSrv';
 import { DashboardSrv, getDashboardSrv } from 'app/features/dashboard/services/DashboardSrv';
 import { getTimeSrv, TimeSrv } from 'app/features/dashboard/services/TimeSrv';
+import { applyRenderOverrides } from 'app/features/dashboard/utils/renderOverrides';
 import {
   HOME_DASHBOARD_CACHE_KEY,
   getDashboardScenePageStateManager,
@@ -209,6 +210,8 @@ export function initDashboard(args: InitDashboardArgs): ThunkResult<void> {
     const storeState = getState();
     const queryParams = locationService.getSearchObject();
 
+    applyRenderOverrides(dashboard, queryParams);
+
     if (!queryParams.orgId) {
       // TODO this is currently not possible with the LocationService API
       locationService.partial({ orgId: storeState.user.orgId }, true);
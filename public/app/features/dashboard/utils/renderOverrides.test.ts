@@ -0,0 +1,147 @@
+import { contextSrv } from 'app/core/services/context_srv';
+
+import { DashboardModel } from '../state/DashboardModel';
+
+import { applyRenderOverrides } from './renderOverrides';
+
+describe('applyRenderOverrides', () => {
+  let dashboard: DashboardModel;
+
+  beforeEach(() => {
+    contextSrv.user.authenticatedBy = 'render';
+    dashboard = new DashboardModel({
+      panels: [
+        {
+          id: 1,
+          type: 'timeseries',
+          maxDataPoints: 500,
+          options: { legend: { showLegend: true, displayMode: 'list', placement: 'bottom', calcs: [] } },
+        },
+        { id: 4, type: 'text', options: { content: 'hello' } },
+        {
+          id: 2,
+          type: 'row',
+          collapsed: true,
+          panels: [{ id: 3, type: 'timeseries', options: { legend: { showLegend: false, placement: 'bottom' } } }],
+        },
+      ],
+    });
+  });
+
+  afterEach(() => {
+    contextSrv.user.authenticatedBy = '';
+    document.body.style.background = '';
+    document.getElementById('render-disable-animations')?.remove();
+  });
+
+  it('should not change the panels outside of renders', () => {
+    contextSrv.user.authenticatedBy = 'password';
+
+    applyRenderOverrides(dashboard, { maxDataPoints: '100' });
+
+    expect(dashboard.panels[0].maxDataPoints).toBe(500);
+  });
+
+  it('should not change the panels without render state', () => {
+    applyRenderOverrides(dashboard, {});
+
+    expect(dashboard.panels[0].maxDataPoints).toBe(500);
+    expect(dashboard.panels[2].panels![0].maxDataPoints).toBeUndefined();
+  });
+
+  it('should apply maxDataPoints to all panels, including collapsed rows', () => {
+    applyRenderOverrides(dashboard, { maxDataPoints: '100' });
+
+    expect(dashboard.panels[0].maxDataPoints).toBe(100);
+    expect(dashboard.panels[2].panels![0].maxDataPoints).toBe(100);
+  });
+
+  it('should ignore an invalid maxDataPoints', () => {
+    applyRenderOverrides(dashboard, { maxDataPoints: '-1' });
+
+    expect(dashboard.panels[0].maxDataPoints).toBe(500);
+  });
+
+  it('should apply the interval to all panels, including collapsed rows', () => {
+    applyRenderOverrides(dashboard, { interval: '5m' });
+
+    expect(dashboard.panels[0].interval).toBe('5m');
+    expect(dashboard.panels[2].panels![0].interval).toBe('5m');
+  });
+
+  it('should hide the legends', () => {
+    applyRenderOverrides(dashboard, { legend: 'hidden' });
+
+    expect(dashboard.panels[0].options.legend).toEqual({
+      showLegend: false,
+      displayMode: 'list',
+      placement: 'bottom',
+      calcs: [],
+    });
+  });
+
+  it('should show the legends on the right', () => {
+    applyRenderOverrides(dashboard, { legend: 'right' });
+
+    expect(dashboard.panels[0].options.legend.placement).toBe('right');
+    expect(dashboard.panels[0].options.legend.showLegend).toBe(true);
+    expect(dashboard.panels[2].panels![0].options.legend).toEqual({ showLegend: true, placement: 'right' });
+  });
+
+  it('should not add a legend to panels without one', () => {
+    applyRenderOverrides(dashboard, { legend: 'right' });
+
+    expect(dashboard.panels[1].options).toEqual({ content: 'hello' });
+  });
+
+  it('should apply the background color to the page and make the panels transparent', () => {
+    applyRenderOverrides(dashboard, { bgColor: '#ff0000' });
+
+    expect(document.body.style.background).toBe('rgb(255, 0, 0)');
+    expect(dashboard.panels[0].transparent).toBe(true);
+    expect(dashboard.panels[2].panels![0].transparent).toBe(true);
+  });
+
+  it('should not change the background outside of renders', () => {
+    contextSrv.user.authenticatedBy = 'password';
+
+    applyRenderOverrides(dashboard, { bgColor: '#ff0000' });
+
+    expect(document.body.style.background).toBe('');
+    expect(dashboard.panels[0].transparent).toBe(false);
+  });
+
+  it('should disable the animations of the page once', () => {
+    applyRenderOverrides(dashboard, { disableAnimations: true });
+    applyRenderOverrides(dashboard, { disableAnimations: 'true' });
+
+    const styles = document.querySelectorAll('#render-disable-animations');
+    expect(styles).toHaveLength(1);
+    expect(styles[0].textContent).toContain('animation: none !important');
+  });
+
+  it('should keep the animations when they are not disabled', () => {
+    applyRenderOverrides(dashboard, { disableAnimations: false });
+
+    expect(document.getElementById('render-disable-animations')).toBeNull();
+  });
+
+  it('should add the field overrides to the panels own overrides', () => {
+    const override = { matcher: { id: 'byName', options: 'cpu' }, properties: [{ id: 'unit', value: 'percent' }] };
+    dashboard.panels[0].fieldConfig.overrides = [{ matcher: { id: 'byName', options: 'mem' }, properties: [] }];
+
+    applyRenderOverrides(dashboard, { fieldOverrides: JSON.stringify([override]) });
+
+    expect(dashboard.panels[0].fieldConfig.overrides).toEqual([
+      { matcher: { id: 'byName', options: 'mem' }, properties: [] },
+      override,
+    ]);
+    expect(dashboard.panels[2].panels![0].fieldConfig.overrides).toEqual([override]);
+  });
+
+  it('should ignore invalid field overrides', () => {
+    applyRenderOverrides(dashboard, { fieldOverrides: '{not json' });
+
+    expect(dashboard.panels[0].fieldConfig.overrides).toEqual([]);
+  });
+});
@@ -0,0 +1,98 @@
+import { ConfigOverrideRule, UrlQueryMap } from '@grafana/data';
+import { locationService } from '@grafana/runtime';
+import { contextSrv } from 'app/core/services/context_srv';
+
+import { DashboardModel } from '../state/DashboardModel';
+import { PanelModel } from '../state/PanelModel';
+
+// applyRenderOverrides applies the render state the image renderer passes in the url, such as &maxDataPoints=,
+// to the panels of a dashboard loaded for a render. It does nothing outside of renders, so the url params can't
+// change how a dashboard is shown to users.
+export function applyRenderOverrides(dashboard: DashboardModel, queryParams?: UrlQueryMap) {
+  if (contextSrv.user.authenticatedBy !== 'render') {
+    return;
+  }
+
+  queryParams = queryParams ?? locationService.getSearchObject();
+  const maxDataPoints = Number(queryParams.maxDataPoints);
+  const interval = queryParams.interval;
+  const legend = queryParams.legend;
+  const bgColor = queryParams.bgColor;
+  const fieldOverrides = parseFieldOverrides(queryParams.fieldOverrides);
+
+  // the page gets the background color, and the panels are made transparent below so that it shows through them
+  if (typeof bgColor === 'string' && bgColor !== '') {
+    document.body.style.background = bgColor;
+  }
+
+  if (queryParams.disableAnimations === true || queryParams.disableAnimations === 'true') {
+    disableAnimations();
+  }
+
+  for (const panel of getAllPanels(dashboard.panels)) {
+    if (Number.isInteger(maxDataPoints) && maxDataPoints > 0) {
+      panel.maxDataPoints = maxDataPoints;
+    }
+
+    // the interval replaces the min interval of the panels' query options, so it can't make the query interval
+    // finer than the time range spread over the max data points
+    if (typeof interval === 'string' && interval !== '') {
+      panel.interval = interval;
+    }
+
+    // only panels that have a legend, such as time series, are changed
+    if (panel.options?.legend && (legend === 'hidden' || legend === 'bottom' || legend === 'right')) {
+      panel.options.legend =
+        legend === 'hidden'
+          ? { ...panel.options.legend, showLegend: false }
+          : { ...panel.options.legend, showLegend: true, placement: legend };
+    }
+
+    if (typeof bgColor === 'string' && bgColor !== '') {
+      panel.transparent = true;
+    }
+
+    // the field overrides apply on top of the panels' own overrides
+    if (fieldOverrides.length > 0) {
+      panel.fieldConfig = {
+        ...panel.fieldConfig,
+        defaults: panel.fieldConfig?.defaults ?? {},
+        overrides: [...(panel.fieldConfig?.overrides ?? []), ...fieldOverrides],
+      };
+    }
+  }
+}
+
+// parseFieldOverrides parses the JSON list of field overrides of a render, which the backend has already validated
+function parseFieldOverrides(value: unknown): ConfigOverrideRule[] {
+  if (typeof value !== 'string' || value === '') {
+    return [];
+  }
+
+  try {
+    const overrides = JSON.parse(value);
+    return Array.isArray(overrides) ? overrides : [];
+  } catch (err) {
+    return [];
+  }
+}
+
+// getAllPanels returns the panels of a dashboard, including the panels of collapsed rows
+function getAllPanels(panels: PanelModel[]): PanelModel[] {
+  return panels.flatMap((panel) => (panel.panels ? [panel, ...getAllPanels(panel.panels)] : [panel]));
+}
+
+const disableAnimationsStyleId = 'render-disable-animations';
+
+// disableAnimations turns off the animations and transitions of the page, so that panels aren't captured while they
+// are still being drawn
+function disableAnimations() {
+  if (document.getElementById(disableAnimationsStyleId)) {
+    return;
+  }
+
+  const style = document.createElement('style');
+  style.id = disableAnimationsStyleId;
+  style.textContent = '*, *::before, *::after { animation: none !important; transition: none !important; }';
+  document.head.appendChild(style);
+}